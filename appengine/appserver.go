@@ -32,16 +32,9 @@ func start(c appengine.Context) error {
 	loadWait = sync.NewCond(&mu)
 	c.Infof("Loading db on instance " + appengine.InstanceID())
 	client := createClient(c, time.Second*30)
-	resp, err := client.Get(dbUrl)
+	db, err = oui.OpenHttp(dbUrl, oui.WithHTTPClient(client))
 	if err != nil {
-		c.Criticalf("Error downloading:%s", err.Error())
-		return err
-	}
-	defer resp.Body.Close()
-	db, err = oui.Open(resp.Body)
-
-	if err != nil {
-		c.Criticalf("Error parsing:%s", err.Error())
+		c.Criticalf("Error loading:%s", err.Error())
 		return err
 	}
 	t := time.Now().Add(time.Hour * 24)
@@ -57,16 +50,9 @@ func update(c appengine.Context) {
 	var err error
 	c.Infof("Updating DB on instance " + appengine.InstanceID())
 	client := createClient(c, time.Second*30)
-	resp, err := client.Get(dbUrl)
-	if err != nil {
-		c.Warningf("Error downloading:%s", err.Error())
-		return
-	}
-	defer resp.Body.Close()
-	err = oui.Update(db, resp.Body)
-
+	err = oui.UpdateHttp(db, dbUrl, oui.WithHTTPClient(client))
 	if err != nil {
-		c.Warningf("Error parsing:%s", err.Error())
+		c.Warningf("Error updating:%s", err.Error())
 		return
 	}
 	t := time.Now().Add(time.Hour * 24)
@@ -103,7 +89,6 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		updating = false
 	}
 	var mac string
-	var hw *oui.HardwareAddr
 
 	// Prepare the response and queue sending the result.
 	res := &Response{}
@@ -129,20 +114,19 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	if mac == "" {
 		mac = strings.Trim(r.URL.Path, "/")
 	}
-	hw, err = oui.ParseMac(mac)
-	if err != nil {
-		res.Error = err.Error() + ". Usage 'http://" + appengine.DefaultVersionHostname(c) + "/AB-CD-EF' (dashes can be colons or omitted)."
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-
-	entry, err := db.LookUp(*hw)
+	// Query the full address, so the most specific entry is found.
+	entry, err := db.Query(mac)
 	if err != nil {
 		if err == oui.ErrNotFound {
 			res.Error = "not found in db"
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		if _, ok := err.(oui.ErrInvalidMac); ok {
+			res.Error = err.Error() + ". Usage 'http://" + appengine.DefaultVersionHostname(c) + "/AB-CD-EF' (dashes can be colons or omitted)."
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		res.Error = err.Error()
 		return
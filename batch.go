@@ -0,0 +1,143 @@
+package oui
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBatchDone is returned when a batch is used after it has been
+// committed or rolled back.
+var ErrBatchDone = errors.New("batch already committed or rolled back")
+
+// The kinds of changes in a batch.
+const (
+	batchUpdate = iota
+	batchDelete
+	batchDeleteBlock
+)
+
+// A single change in a batch.
+type batchOp struct {
+	kind  int
+	hw    HardwareAddr
+	entry Entry
+	block Block
+}
+
+// Batch collects changes to a dynamic database, which are applied
+// atomically when Commit is called. Queries will see either the content
+// before the batch or the content with all the changes, never a mix.
+// Create a batch with the Begin method of the database.
+//
+// The changes are applied to the content of the database at the time of Commit,
+// in the order they were made. If the content was replaced since the batch
+// was started, the changes are applied to the new content.
+//
+// A Batch can be used from several goroutines.
+type Batch struct {
+	db   *updateableDB
+	mu   sync.Mutex
+	ops  []batchOp
+	done bool
+}
+
+// Begin starts a new batch of changes to the database.
+// Nothing is changed until Commit is called on the batch.
+func (o *updateableDB) Begin() *Batch {
+	return &Batch{db: o}
+}
+
+// Add a change to the batch.
+func (b *Batch) add(op batchOp) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return ErrBatchDone
+	}
+	b.ops = append(b.ops, op)
+	return nil
+}
+
+// UpdateEntry will update/add a single entry when the batch is committed.
+// If the entry has a Block, it is stored as a block entry.
+func (b *Batch) UpdateEntry(hw HardwareAddr, e Entry) error {
+	return b.add(batchOp{kind: batchUpdate, hw: hw, entry: e})
+}
+
+// DeleteEntry will remove an entry when the batch is committed.
+// If the element does not exist at that time, nothing happens.
+func (b *Batch) DeleteEntry(hw HardwareAddr) error {
+	return b.add(batchOp{kind: batchDelete, hw: hw})
+}
+
+// DeleteBlock will remove a MA-M or MA-S entry when the batch is committed.
+// If the element does not exist at that time, nothing happens.
+func (b *Batch) DeleteBlock(blk Block) error {
+	return b.add(batchOp{kind: batchDeleteBlock, block: blk})
+}
+
+// Len returns the number of changes in the batch.
+func (b *Batch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.ops)
+}
+
+// Commit applies all the changes in the batch to the database at once.
+// After Commit the batch cannot be used.
+func (b *Batch) Commit() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return ErrBatchDone
+	}
+	b.done = true
+	if len(b.ops) == 0 {
+		return nil
+	}
+	b.db.modify(func(s *dbState) []Event {
+		var oui, blocks bool
+		for _, op := range b.ops {
+			switch {
+			case op.kind == batchDeleteBlock, op.kind == batchUpdate && op.entry.Block != nil:
+				blocks = true
+			default:
+				oui = true
+			}
+		}
+		if oui {
+			s.ouiDB = s.ouiDB.copyOui()
+		}
+		if blocks {
+			s.ouiDB = s.ouiDB.copyBlocks()
+		}
+		event := len(b.db.subs) > 0
+		var events []Event
+		for _, op := range b.ops {
+			switch op.kind {
+			case batchUpdate:
+				events = append(events, s.update(op.hw, op.entry, event)...)
+			case batchDelete:
+				events = append(events, s.delete(op.hw, event)...)
+			case batchDeleteBlock:
+				events = append(events, s.deleteBlock(op.block, event)...)
+			}
+		}
+		return events
+	})
+	b.ops = nil
+	return nil
+}
+
+// Rollback discards all the changes in the batch.
+// After Rollback the batch cannot be used.
+func (b *Batch) Rollback() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return ErrBatchDone
+	}
+	b.done = true
+	b.ops = nil
+	return nil
+}
@@ -0,0 +1,90 @@
+package oui
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	db := testDynamic(t)
+	n := db.Len()
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	b := db.Begin()
+	b.UpdateEntry(HardwareAddr{0x02, 0, 0}, Entry{Prefix: HardwareAddr{0x02, 0, 0}, Manufacturer: "New"})
+	b.DeleteEntry(hw)
+	if b.Len() != 2 {
+		t.Fatalf("want 2 changes, got %d", b.Len())
+	}
+	// Nothing changes before Commit.
+	if _, err := db.LookUp(hw); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LookUp(hw); err != ErrNotFound {
+		t.Fatalf("entry not deleted: %v", err)
+	}
+	if db.Len() != n {
+		t.Fatalf("want %d entries, got %d", n, db.Len())
+	}
+	if err := b.Commit(); err != ErrBatchDone {
+		t.Fatalf("want ErrBatchDone, got %v", err)
+	}
+	if err := b.UpdateEntry(hw, Entry{Prefix: hw}); err != ErrBatchDone {
+		t.Fatalf("want ErrBatchDone, got %v", err)
+	}
+
+	b = db.Begin()
+	b.DeleteEntry(HardwareAddr{0x02, 0, 0})
+	if err := b.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.LookUp(HardwareAddr{0x02, 0, 0}); err != nil {
+		t.Fatalf("rolled back change was applied: %v", err)
+	}
+}
+
+func TestBatchAtomic(t *testing.T) {
+	db := testDynamic(t)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	blk := NewBlock(MAC{0x70, 0xb3, 0xd5, 0xf2, 0xc0, 0x00}, 36)
+	commit := func(name string) {
+		b := db.Begin()
+		b.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: name})
+		b.UpdateEntry(blk.OUI(), Entry{Prefix: blk.OUI(), Block: &blk, Manufacturer: name})
+		if err := b.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit("start")
+
+	// Readers must see both entries from the same batch.
+	var stop int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				var names []string
+				for e := range db.All() {
+					if e.Prefix == hw && e.Block == nil || e.Block != nil && *e.Block == blk {
+						names = append(names, e.Manufacturer)
+					}
+				}
+				if len(names) != 2 || names[0] != names[1] {
+					t.Errorf("saw a partial batch: %q", names)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 200; i++ {
+		commit(strconv.Itoa(i))
+	}
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+}
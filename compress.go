@@ -0,0 +1,152 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression is the compression of the input.
+type Compression int
+
+const (
+	// CompressionAuto will detect compression from the content,
+	// the file extension or the HTTP Content-Encoding.
+	CompressionAuto Compression = iota
+	// CompressionNone will read the input as it is.
+	CompressionNone
+	// CompressionGzip is gzip compressed input.
+	CompressionGzip
+	// CompressionBzip2 is bzip2 compressed input.
+	CompressionBzip2
+	// CompressionXz is xz compressed input.
+	CompressionXz
+	// CompressionZstd is zstandard compressed input.
+	CompressionZstd
+)
+
+// String returns the name of the compression.
+func (c Compression) String() string {
+	switch c {
+	case CompressionAuto:
+		return "auto"
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionBzip2:
+		return "bzip2"
+	case CompressionXz:
+		return "xz"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return "unknown"
+}
+
+// WithCompression sets the compression of the input.
+// If the compression isn't specified or CompressionAuto is given,
+// the compression is detected from the content, the file extension
+// or the HTTP Content-Encoding.
+func WithCompression(c Compression) Option {
+	return func(o *options) {
+		o.compression = c
+	}
+}
+
+// The magic bytes the compressed formats start with.
+var compressionMagic = []struct {
+	c     Compression
+	magic []byte
+}{
+	{c: CompressionGzip, magic: []byte{0x1f, 0x8b}},
+	{c: CompressionBzip2, magic: []byte("BZh")},
+	{c: CompressionXz, magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{c: CompressionZstd, magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// compressionFromName returns the compression given by the file extension.
+func compressionFromName(name string) Compression {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".gzip":
+		return CompressionGzip
+	case ".bz2":
+		return CompressionBzip2
+	case ".xz":
+		return CompressionXz
+	case ".zst", ".zstd":
+		return CompressionZstd
+	}
+	return CompressionAuto
+}
+
+// compressionFromEncoding returns the compression given by a HTTP Content-Encoding.
+func compressionFromEncoding(enc string) Compression {
+	switch strings.ToLower(strings.TrimSpace(enc)) {
+	case "gzip", "x-gzip":
+		return CompressionGzip
+	case "bzip2", "x-bzip2":
+		return CompressionBzip2
+	case "xz":
+		return CompressionXz
+	case "zstd":
+		return CompressionZstd
+	}
+	return CompressionAuto
+}
+
+// detectCompression will examine the beginning of the input and return the compression.
+// If the content isn't recognized, the hint is returned if set.
+func detectCompression(r *bufio.Reader, hint Compression) Compression {
+	b, _ := r.Peek(8)
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(b, m.magic) {
+			return m.c
+		}
+	}
+	if hint != CompressionAuto {
+		return hint
+	}
+	return CompressionNone
+}
+
+// decompress returns a reader that decompresses the input
+// with the compression given by the options.
+// The returned function must be called when done reading.
+func decompress(r *bufio.Reader, o *options) (io.Reader, func(), error) {
+	c := o.compression
+	if c == CompressionAuto {
+		c = detectCompression(r, o.hint)
+	}
+	nop := func() {}
+	switch c {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nop, err
+		}
+		return zr, func() { zr.Close() }, nil
+	case CompressionBzip2:
+		return bzip2.NewReader(r), nop, nil
+	case CompressionXz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, nop, err
+		}
+		return xr, nop, nil
+	case CompressionZstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nop, err
+		}
+		return zr, zr.Close, nil
+	}
+	return r, nop, nil
+}
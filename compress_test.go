@@ -0,0 +1,161 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// httpContent compressed with "bzip2 -9", since there is no bzip2 writer.
+const bzip2Content = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\xba\xb0\x06\xbe\x00\x00\x06\x5f\x80\x20\x10\x40\x00\x70\x00\x00\x00\x84\x00\x02\x01\x80\x80\x20\x00\x31\x03\x40\xd0\x12\xa6\x9a\x3d\x00\xe5\xd2\x8f\x1c\x42\x20\xba\xcc\xd1\x77\x24\x53\x85\x09\x0b\xab\x00\x6b\xe0"
+
+// Return the content compressed with c.
+func compressed(t *testing.T, c Compression, content []byte) []byte {
+	if c == CompressionBzip2 {
+		if !bytes.Equal(content, []byte(httpContent)) {
+			t.Fatal("only httpContent can be compressed with bzip2")
+		}
+		return []byte(bzip2Content)
+	}
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch c {
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionXz:
+		w, err = xz.NewWriter(&buf)
+	case CompressionZstd:
+		w, err = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("cannot compress with %v", c)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompression(t *testing.T) {
+	var text bytes.Buffer
+	if err := Export(testDB(t), &text, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	want := testDB(t)
+	for _, c := range []Compression{CompressionGzip, CompressionXz, CompressionZstd} {
+		in := compressed(t, c, text.Bytes())
+		// Detected from the content, and given explicitly.
+		for _, opts := range [][]Option{nil, {WithCompression(c)}} {
+			db, err := OpenStatic(bytes.NewReader(in), opts...)
+			if err != nil {
+				t.Fatalf("%v: %v", c, err)
+			}
+			if changes := Diff(want, db); len(changes) != 0 || !db.Generated().Equal(want.Generated()) {
+				t.Errorf("%v: content differs: %+v", c, changes)
+			}
+		}
+	}
+
+	db, err := OpenStatic(bytes.NewReader([]byte(bzip2Content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := db.Query("00:00:02"); err != nil || e.Manufacturer != "Two" || db.Len() != 2 {
+		t.Fatalf("bzip2: unexpected entry %v %v", e, err)
+	}
+
+	// Uncompressed content is read as it is.
+	db, err = OpenStatic(bytes.NewReader(text.Bytes()), WithCompression(CompressionNone))
+	if err != nil || db.Len() != want.Len() {
+		t.Fatalf("uncompressed: %v", err)
+	}
+}
+
+func TestCompressionHint(t *testing.T) {
+	for name, want := range map[string]Compression{
+		"oui.txt.gz":  CompressionGzip,
+		"oui.GZIP":    CompressionGzip,
+		"manuf.bz2":   CompressionBzip2,
+		"oui.csv.xz":  CompressionXz,
+		"oui.txt.zst": CompressionZstd,
+		"oui.zstd":    CompressionZstd,
+		"oui.txt":     CompressionAuto,
+	} {
+		if got := compressionFromName(name); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	for enc, want := range map[string]Compression{
+		"gzip":     CompressionGzip,
+		"x-gzip":   CompressionGzip,
+		"bzip2":    CompressionBzip2,
+		"xz":       CompressionXz,
+		" ZSTD ":   CompressionZstd,
+		"identity": CompressionAuto,
+		"":         CompressionAuto,
+	} {
+		if got := compressionFromEncoding(enc); got != want {
+			t.Errorf("%q: got %v, want %v", enc, got, want)
+		}
+	}
+
+	// The content takes precedence over the hint.
+	r := bufio.NewReader(bytes.NewReader(compressed(t, CompressionGzip, []byte(httpContent))))
+	if c := detectCompression(r, CompressionZstd); c != CompressionGzip {
+		t.Errorf("got %v, want gzip", c)
+	}
+	r = bufio.NewReader(bytes.NewReader([]byte(httpContent)))
+	if c := detectCompression(r, CompressionXz); c != CompressionXz {
+		t.Errorf("hint not used, got %v", c)
+	}
+	if c := detectCompression(r, CompressionAuto); c != CompressionNone {
+		t.Errorf("got %v, want none", c)
+	}
+}
+
+func TestOpenFileGzip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "oui.txt.gz")
+	if err := os.WriteFile(name, compressed(t, CompressionGzip, []byte(httpContent)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenFile(name, WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := db.Query("00:00:01"); err != nil || e.Manufacturer != "One" || db.Len() != 2 {
+		t.Fatalf("unexpected entry %v %v", e, err)
+	}
+}
+
+func TestHttpContentEncoding(t *testing.T) {
+	for _, c := range []Compression{CompressionBzip2, CompressionXz, CompressionZstd} {
+		body := compressed(t, c, []byte(httpContent))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", c.String())
+			w.Write(body)
+		}))
+		db, err := OpenHttp(srv.URL, WithFormat(FormatNmap))
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%v: %v", c, err)
+		}
+		if db.Len() != 2 {
+			t.Errorf("%v: got %d entries", c, db.Len())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package oui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The columns of the IEEE CSV files.
+const (
+	csvRegistry   = "Registry"
+	csvAssignment = "Assignment"
+	csvName       = "Organization Name"
+	csvAddress    = "Organization Address"
+)
+
+// Reads an IEEE CSV file.
+// This reads the format used by oui.csv, mam.csv, oui36.csv, iab.csv and cid.csv.
+// The files contain no generation time, so the generation time is always nil.
+type csvReader struct {
+	r       *csv.Reader
+	p       *parseState
+	cols    map[string]int
+	addr    int
+	hasAddr bool
+}
+
+// Create a reader of the CSV format.
+func newCSVReader(in io.Reader, p *parseState) *csvReader {
+	r := csv.NewReader(in)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return &csvReader{r: r, p: p}
+}
+
+// Return the generation time, which is always nil.
+func (r *csvReader) generated() *time.Time {
+	return nil
+}
+
+// Read the header and locate the columns.
+func (r *csvReader) header() error {
+	header, err := r.r.Read()
+	if err != nil {
+		return err
+	}
+	r.cols = make(map[string]int)
+	for i, h := range header {
+		r.cols[strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))] = i
+	}
+	for _, c := range []string{csvRegistry, csvAssignment, csvName} {
+		if _, ok := r.cols[c]; !ok {
+			return &ParseError{Source: r.p.source, Line: 1, Text: strings.Join(header, ","), Reason: fmt.Sprintf("missing column %q", c)}
+		}
+	}
+	r.addr, r.hasAddr = r.cols[csvAddress]
+	return nil
+}
+
+// Return the next entry.
+func (r *csvReader) next() (*Entry, error) {
+	if r.cols == nil {
+		if err := r.header(); err != nil {
+			return nil, err
+		}
+	}
+	p, cols := r.p, r.cols
+	for {
+		rec, err := r.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		field := func(i int) string {
+			if i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+		line, _ := r.r.FieldPos(0)
+		a := field(cols[csvAssignment])
+		if a == "" {
+			if err := p.problem(line, strings.Join(rec, ","), "missing assignment"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e, err := assignmentEntry(a)
+		if err != nil {
+			if err := p.problem(line, strings.Join(rec, ","), err.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e.Registry = Registry(field(cols[csvRegistry]))
+		e.Manufacturer = field(cols[csvName])
+		if e.Manufacturer == "" {
+			if err := p.problem(line, strings.Join(rec, ","), "missing organization name"); err != nil {
+				return nil, err
+			}
+		}
+		if r.hasAddr {
+			if s := field(r.addr); s != "" {
+				e.Address = []string{s}
+			}
+		}
+		return e, nil
+	}
+}
+
+// assignmentEntry returns an entry with the prefix of an assignment
+// written as hex digits, for instance "002272" or "70B3D5F2C".
+// The number of digits determine the length of the prefix.
+// Only OUI, MA-M and MA-S lengths are accepted, since other blocks cannot be looked up.
+func assignmentEntry(a string) (*Entry, error) {
+	switch len(a) {
+	case 6, 7, 9:
+	default:
+		return nil, ErrInvalidMac{Reason: "Assignment must be 6, 7 or 9 hex digits", Mac: a}
+	}
+	v, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return nil, ErrInvalidMac{Reason: fmt.Sprintf("Assignment cannot be parsed as hex value: %v", err), Mac: a}
+	}
+	bits := len(a) * 4
+	v <<= uint(48 - bits)
+	var m MAC
+	for i := range m {
+		m[len(m)-1-i] = byte(v >> uint(8*i))
+	}
+	e := Entry{Prefix: m.OUI()}
+	if bits > 24 {
+		b := NewBlock(m, bits)
+		e.Block = &b
+	}
+	e.Local = e.Prefix.Local()
+	e.Multicast = e.Prefix.Multicast()
+	return &e, nil
+}
+
+// OpenStaticCSV will read the content of an IEEE CSV file from the given reader
+// and return a database with the content.
+// This is the same as calling OpenStatic with the FormatCSV option.
+func OpenStaticCSV(in io.Reader, opts ...Option) (StaticDB, error) {
+	return OpenStatic(in, append(opts, WithFormat(FormatCSV))...)
+}
+
+// OpenCSV will read the content of an IEEE CSV file from the given reader
+// and return a database with the content.
+// This is the same as calling Open with the FormatCSV option.
+func OpenCSV(in io.Reader, opts ...Option) (DynamicDB, error) {
+	return Open(in, append(opts, WithFormat(FormatCSV))...)
+}
+
+// UpdateCSV will read an IEEE CSV file and replace the content of the database.
+// This is the same as calling Update with the FormatCSV option.
+func UpdateCSV(db DynamicDB, r io.Reader, opts ...Option) error {
+	return Update(db, r, append(opts, WithFormat(FormatCSV))...)
+}
+
+// Write entries in the IEEE CSV format.
+func writeCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{csvRegistry, csvAssignment, csvName, csvAddress})
+	for i := range entries {
+		e := &entries[i]
+		cw.Write([]string{string(registry(e)), prefixHex(e), e.Manufacturer, strings.Join(e.Address, " ")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
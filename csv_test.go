@@ -0,0 +1,89 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+const csvMAM = "\ufeffRegistry,Assignment,Organization Name,Organization Address\n" +
+	"MA-L,002272,American Micro-Fuel Device Corp.,\"2181 Buchanan Loop Ferndale WA US 98248 \"\n" +
+	"MA-M,70B3D5F,Tiny IoT,Other Street DE\n" +
+	"MA-S,70B3D5F2C,\"Smallest, Inc.\",\n"
+
+func TestCSV(t *testing.T) {
+	db, err := OpenCSV(strings.NewReader(csvMAM))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Len(); n != 3 {
+		t.Fatalf("want 3 entries, got %d", n)
+	}
+	e, err := db.Query("00:22:72")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "American Micro-Fuel Device Corp." || e.Registry != "MA-L" || e.Block != nil {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	if len(e.Address) != 1 || e.Address[0] != "2181 Buchanan Loop Ferndale WA US 98248" {
+		t.Fatalf("unexpected address %q", e.Address)
+	}
+
+	// The most specific block is returned.
+	for mac, want := range map[string]string{
+		"70:b3:d5:f2:c1:a5": "Smallest, Inc.",
+		"70:b3:d5:f3:00:00": "Tiny IoT",
+	} {
+		e, err := db.Query(mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Manufacturer != want {
+			t.Errorf("%s: got %q, want %q", mac, e.Manufacturer, want)
+		}
+	}
+	e, _ = db.Query("70:b3:d5:f2:c1:a5")
+	if e.Block == nil || e.Block.Bits != 36 || e.Registry != "MA-S" {
+		t.Fatalf("unexpected block entry %v", e)
+	}
+}
+
+func TestCSVMissingColumn(t *testing.T) {
+	_, err := OpenCSV(strings.NewReader("Registry,Organization Name\nMA-L,Foo\n"))
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("want *ParseError, got %v", err)
+	}
+}
+
+func TestCSVInvalidAssignment(t *testing.T) {
+	const in = "Registry,Assignment,Organization Name\nMA-L,00227Z,Bad\nMA-L,002272,Good\n"
+	var report ParseReport
+	db, err := OpenCSV(strings.NewReader(in), WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 1 || len(report.Warnings) != 1 || report.Warnings[0].Line != 2 {
+		t.Fatalf("got %d entries and warnings %v", db.Len(), report.Warnings)
+	}
+	if _, err := OpenCSV(strings.NewReader(in), WithStrict()); err == nil {
+		t.Fatal("strict parsing accepted an invalid assignment")
+	}
+}
+
+func TestCSVAssignmentLength(t *testing.T) {
+	// Only OUI, MA-M and MA-S assignments can be looked up.
+	for _, a := range []string{"00112233", "0011223344", "00112233445", "001122334455"} {
+		in := "Registry,Assignment,Organization Name\nMA-S," + a + ",Too Long\n"
+		var report ParseReport
+		db, err := OpenCSV(strings.NewReader(in), WithReport(&report))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if db.Len() != 0 || len(report.Warnings) != 1 {
+			t.Errorf("%s: got %d entries and warnings %v", a, db.Len(), report.Warnings)
+		}
+		if _, err := OpenCSV(strings.NewReader(in), WithStrict()); err == nil {
+			t.Errorf("%s: strict parsing accepted the assignment", a)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package oui
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoDefault is returned by Default if no default database has been registered.
+// Importing the "github.com/klauspost/oui/embedded" package will register one.
+var ErrNoDefault = errors.New("no default database registered")
+
+var (
+	defaultMu   sync.Mutex
+	defaultOpen func() (StaticDB, error)
+	defaultDB   StaticDB
+	defaultErr  error
+	defaultDone bool
+)
+
+// RegisterDefault will register the function that opens the default database.
+// It is intended to be called from the init function of packages
+// that contain a database, like "github.com/klauspost/oui/embedded".
+// The function is called on the first call to Default.
+func RegisterDefault(open func() (StaticDB, error)) {
+	defaultMu.Lock()
+	defaultOpen = open
+	defaultDB, defaultErr, defaultDone = nil, nil, false
+	defaultMu.Unlock()
+}
+
+// Default returns the default database.
+// The database is loaded on first use, and the same database
+// is returned on subsequent calls.
+// If no default database has been registered ErrNoDefault is returned.
+func Default() (StaticDB, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultDone {
+		return defaultDB, defaultErr
+	}
+	if defaultOpen == nil {
+		return nil, ErrNoDefault
+	}
+	defaultDB, defaultErr = defaultOpen()
+	defaultDone = true
+	return defaultDB, defaultErr
+}
@@ -0,0 +1,145 @@
+package oui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ChangeKind is the kind of change between two databases.
+type ChangeKind int
+
+const (
+	// ChangeAdded is a prefix that is only in the new database.
+	ChangeAdded ChangeKind = iota
+	// ChangeRemoved is a prefix that is only in the old database.
+	ChangeRemoved
+	// ChangeReassigned is a prefix assigned to a different organization.
+	ChangeReassigned
+	// ChangeManufacturer is a prefix where the organization name was changed,
+	// but only in case, punctuation or company suffix, for instance "Acme, Inc." to "ACME INC".
+	ChangeManufacturer
+	// ChangeAddress is a prefix where the address or country was changed.
+	ChangeAddress
+	// ChangeOther is a prefix where other information, like the registry
+	// or short name, was changed.
+	ChangeOther
+)
+
+// String returns the name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeReassigned:
+		return "reassigned"
+	case ChangeManufacturer:
+		return "manufacturer"
+	case ChangeAddress:
+		return "address"
+	case ChangeOther:
+		return "other"
+	}
+	return "unknown"
+}
+
+// MarshalText returns the name of the change kind.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change describes the difference of a prefix between two databases.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	// Prefix is the prefix, for instance "00:60:92" or "70:b3:d5:f2:c0:00/36".
+	Prefix string `json:"prefix"`
+	// Old is the entry in the old database, nil if added.
+	Old *Entry `json:"old,omitempty"`
+	// New is the entry in the new database, nil if removed.
+	New *Entry `json:"new,omitempty"`
+}
+
+// Diff returns the changes from database a to database b,
+// sorted by prefix. Each prefix has at most one change.
+// If several things were changed, the most significant kind is reported,
+// in the order reassigned, manufacturer, address and other.
+func Diff(a, b OuiDB) []Change {
+	ea, eb := a.entries(), b.entries()
+	var res []Change
+	for len(ea) > 0 || len(eb) > 0 {
+		switch {
+		case len(eb) == 0 || len(ea) > 0 && entryLess(&ea[0], &eb[0]):
+			e := ea[0]
+			res = append(res, Change{Kind: ChangeRemoved, Prefix: entryPrefix(&e), Old: &e})
+			ea = ea[1:]
+		case len(ea) == 0 || entryLess(&eb[0], &ea[0]):
+			e := eb[0]
+			res = append(res, Change{Kind: ChangeAdded, Prefix: entryPrefix(&e), New: &e})
+			eb = eb[1:]
+		default:
+			old, new := ea[0], eb[0]
+			if kind, changed := changeKind(&old, &new); changed {
+				res = append(res, Change{Kind: kind, Prefix: entryPrefix(&new), Old: &old, New: &new})
+			}
+			ea, eb = ea[1:], eb[1:]
+		}
+	}
+	return res
+}
+
+// Return the prefix of an entry as a string.
+func entryPrefix(e *Entry) string {
+	if e.Block != nil {
+		return e.Block.String()
+	}
+	return e.Prefix.String()
+}
+
+// Return the kind of change between two entries with the same prefix,
+// and false if they are equal.
+func changeKind(a, b *Entry) (ChangeKind, bool) {
+	switch {
+	case entryEqual(a, b):
+		return 0, false
+	case normalizeName(a.Manufacturer) != normalizeName(b.Manufacturer):
+		return ChangeReassigned, true
+	case a.Manufacturer != b.Manufacturer:
+		return ChangeManufacturer, true
+	case a.Country != b.Country || !stringsEqual(a.Address, b.Address):
+		return ChangeAddress, true
+	}
+	return ChangeOther, true
+}
+
+// Company suffixes ignored when comparing organization names.
+var companySuffixes = map[string]bool{
+	"AG": true, "BV": true, "CO": true, "COMPANY": true, "CORP": true,
+	"CORPORATION": true, "GMBH": true, "INC": true, "INCORPORATED": true,
+	"LIMITED": true, "LLC": true, "LTD": true, "PLC": true, "SA": true,
+}
+
+// normalizeName returns the organization name in upper case,
+// with punctuation and company suffixes removed.
+func normalizeName(s string) string {
+	words := strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for len(words) > 1 && companySuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// Return whether two string slices are equal.
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
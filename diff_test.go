@@ -0,0 +1,110 @@
+package oui
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	old := testDB(t)
+	db := testDynamic(t)
+	get := func(mac string) Entry {
+		e, err := db.Query(mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *e
+	}
+
+	// Only the case, punctuation and suffix of the name changed.
+	e := get("00:60:92")
+	e.Manufacturer = "Micro Sys Inc"
+	db.UpdateEntry(e.Prefix, e)
+
+	// Another organization, with another address.
+	e = get("00:60:94")
+	e.Manufacturer = "Lenovo"
+	e.Address = []string{"Beijing"}
+	db.UpdateEntry(e.Prefix, e)
+
+	// A new address.
+	e = get("70:b3:d5:f2:c1:00")
+	e.Address = append([]string{"New Street"}, e.Address[1:]...)
+	db.UpdateEntry(e.Prefix, e)
+
+	removed := get("70:b3:d5:f3:00:00")
+	db.DeleteBlock(*removed.Block)
+	hw := HardwareAddr{0x00, 0x00, 0x01}
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "New Corp"})
+
+	want := []struct {
+		prefix string
+		kind   ChangeKind
+	}{
+		{"00:00:01", ChangeAdded},
+		{"00:60:92", ChangeManufacturer},
+		{"00:60:94", ChangeReassigned},
+		{"70:b3:d5:f0:00:00/28", ChangeRemoved},
+		{"70:b3:d5:f2:c0:00/36", ChangeAddress},
+	}
+	changes := Diff(old, db)
+	if len(changes) != len(want) {
+		t.Fatalf("want %d changes, got %+v", len(want), changes)
+	}
+	for i, w := range want {
+		c := changes[i]
+		if c.Prefix != w.prefix || c.Kind != w.kind {
+			t.Errorf("change %d: got %s %v, want %s %v", i, c.Prefix, c.Kind, w.prefix, w.kind)
+		}
+		if (c.Old == nil) != (c.Kind == ChangeAdded) || (c.New == nil) != (c.Kind == ChangeRemoved) {
+			t.Errorf("change %d: unexpected entries %+v", i, c)
+		}
+	}
+	if changes := Diff(old, old); len(changes) != 0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestChangeKind(t *testing.T) {
+	base := Entry{Manufacturer: "Acme, Inc.", Address: []string{"1 Main St"}, Country: "US", ShortName: "Acme"}
+	for _, tc := range []struct {
+		name   string
+		change func(e *Entry)
+		kind   ChangeKind
+	}{
+		{"manufacturer", func(e *Entry) { e.Manufacturer = "ACME INC" }, ChangeManufacturer},
+		{"suffix", func(e *Entry) { e.Manufacturer = "Acme Corporation" }, ChangeManufacturer},
+		{"reassigned", func(e *Entry) { e.Manufacturer = "Acme Robotics, Inc." }, ChangeReassigned},
+		{"reassigned and moved", func(e *Entry) { e.Manufacturer = "Other Ltd"; e.Country = "DE" }, ChangeReassigned},
+		{"renamed and moved", func(e *Entry) { e.Manufacturer = "ACME INC"; e.Country = "DE" }, ChangeManufacturer},
+		{"address", func(e *Entry) { e.Address = []string{"2 Main St"} }, ChangeAddress},
+		{"country", func(e *Entry) { e.Country = "CA" }, ChangeAddress},
+		{"short name", func(e *Entry) { e.ShortName = "ACME" }, ChangeOther},
+		{"registry", func(e *Entry) { e.Registry = "MA-L" }, ChangeOther},
+	} {
+		e := base
+		e.Address = append([]string(nil), base.Address...)
+		tc.change(&e)
+		kind, changed := changeKind(&base, &e)
+		if !changed || kind != tc.kind {
+			t.Errorf("%s: got %v %v, want %v", tc.name, kind, changed, tc.kind)
+		}
+	}
+	same := base
+	if _, changed := changeKind(&base, &same); changed {
+		t.Error("equal entries reported as changed")
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for in, want := range map[string]string{
+		"Acme, Inc.":           "ACME",
+		"ACME INC":             "ACME",
+		"Acme Co., Ltd.":       "ACME",
+		"MICRO/SYS, INC.":      "MICRO SYS",
+		"Hewlett-Packard GmbH": "HEWLETT PACKARD",
+		"Inc":                  "INC",
+		"Acme Inc. Robotics":   "ACME INC ROBOTICS",
+	} {
+		if got := normalizeName(in); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
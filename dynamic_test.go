@@ -0,0 +1,134 @@
+package oui
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Return a dynamic database with the content of testDB.
+func testDynamic(tb testing.TB, opts ...Option) DynamicDB {
+	var buf bytes.Buffer
+	if err := Export(testDB(tb), &buf, FormatSnapshot); err != nil {
+		tb.Fatal(err)
+	}
+	db, err := Open(&buf, opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	db := testDynamic(t)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	mac := MAC{0x70, 0xb3, 0xd5, 0xf2, 0xc1, 0x00}
+	old := db.(*updateableDB).load()
+
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Changed"})
+	e, _ := db.LookUpMAC(mac)
+	db.DeleteBlock(*e.Block)
+
+	// The old content is not affected by the changes.
+	if e, err := old.lookUp(MAC{0x00, 0x60, 0x92}, 3); err != nil || e.Manufacturer != "MICRO/SYS, INC." {
+		t.Fatalf("old content changed: %v %v", e, err)
+	}
+	if e, err := old.lookUp(mac, 6); err != nil || e.Manufacturer != "Tiny IoT" {
+		t.Fatalf("old block content changed: %v %v", e, err)
+	}
+	if e, err := db.LookUp(hw); err != nil || e.Manufacturer != "Changed" {
+		t.Fatalf("update not visible: %v %v", e, err)
+	}
+	if e, err := db.LookUpMAC(mac); err != nil || e.Manufacturer != "Big Umbrella" {
+		t.Fatalf("block delete not visible: %v %v", e, err)
+	}
+	if db.Len() != old.len()-1 {
+		t.Fatalf("want %d entries, got %d", old.len()-1, db.Len())
+	}
+}
+
+func TestConcurrentReadWrite(t *testing.T) {
+	db := testDynamic(t)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	names := []string{"MICRO/SYS, INC.", "A", "B"}
+	var stop int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				e, err := db.LookUp(hw)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if e.Manufacturer != names[0] && e.Manufacturer != names[1] && e.Manufacturer != names[2] {
+					t.Errorf("unexpected manufacturer %q", e.Manufacturer)
+					return
+				}
+				if _, err := db.Query("70:b3:d5:f2:c1:00"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	var snap bytes.Buffer
+	if err := WriteSnapshot(db, &snap); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: names[1+i%2]})
+		if i%100 == 0 {
+			if err := Update(db, bytes.NewReader(snap.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+}
+
+// Run parallel lookups of a full address.
+// Run with -cpu 1,2,4 to see how lookups scale.
+func benchmarkLookUp(b *testing.B, db DynamicDB) {
+	mac := MAC{0x70, 0xb3, 0xd5, 0xf2, 0xc1, 0x00}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := db.LookUpMAC(mac); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkLookUpParallel(b *testing.B) {
+	benchmarkLookUp(b, testDynamic(b))
+}
+
+func BenchmarkLookUpParallelWithWriter(b *testing.B) {
+	db := testDynamic(b)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	e, err := db.LookUp(hw)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Update an entry continuously while looking up.
+	var stop int32
+	done := make(chan struct{})
+	go func() {
+		for atomic.LoadInt32(&stop) == 0 {
+			db.UpdateEntry(hw, *e)
+		}
+		close(done)
+	}()
+	benchmarkLookUp(b, db)
+	atomic.StoreInt32(&stop, 1)
+	<-done
+}
@@ -0,0 +1,46 @@
+// Package embedded contains an embedded copy of the IEEE registries.
+//
+// Importing the package will register the embedded database as the
+// default database, so it can be accessed with oui.Default():
+//
+//	import (
+//		"github.com/klauspost/oui"
+//		_ "github.com/klauspost/oui/embedded"
+//	)
+//
+//	db, err := oui.Default()
+//
+// The database is stored as a compressed snapshot, and is decompressed
+// on first use. To update the embedded database run "go generate".
+// If the snapshot file is empty, opening it returns ErrNotGenerated.
+package embedded
+
+//go:generate go run gen.go -out oui.snap.gz
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+
+	"github.com/klauspost/oui"
+)
+
+// ErrNotGenerated is returned if the embedded snapshot is empty,
+// because "go generate" hasn't been run in this package.
+var ErrNotGenerated = errors.New("embedded: snapshot has not been generated, run 'go generate' in the embedded package")
+
+//go:embed oui.snap.gz
+var snapshot []byte
+
+func init() {
+	oui.RegisterDefault(Open)
+}
+
+// Open will read the embedded snapshot and return a database with the content.
+// Every call returns a new database, use oui.Default() to share a single database.
+func Open() (oui.StaticDB, error) {
+	if len(snapshot) == 0 {
+		return nil, ErrNotGenerated
+	}
+	return oui.OpenStaticSnapshot(bytes.NewReader(snapshot))
+}
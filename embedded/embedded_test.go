@@ -0,0 +1,30 @@
+package embedded
+
+import (
+	"testing"
+
+	"github.com/klauspost/oui"
+)
+
+func TestDefault(t *testing.T) {
+	db, err := oui.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The IEEE registries contain tens of thousands of entries,
+	// anything less means test data was embedded.
+	if n := db.Len(); n < 10000 {
+		t.Fatalf("embedded snapshot only has %d entries", n)
+	}
+	e, err := db.Query("00:00:0c:12:34:56")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Cisco Systems, Inc" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	db2, err := oui.Default()
+	if err != nil || db2 != db {
+		t.Fatal("Default returned a different database")
+	}
+}
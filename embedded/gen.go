@@ -0,0 +1,94 @@
+//go:build ignore
+// +build ignore
+
+package main
+
+// To run, execute: go generate
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/oui"
+)
+
+var sources = flag.String("src", "http://standards-oui.ieee.org/oui.txt,http://standards-oui.ieee.org/oui28/mam.txt,http://standards-oui.ieee.org/oui36/oui36.txt", "Comma separated list of files or URLs to include.")
+var out = flag.String("out", "oui.snap.gz", "Output file name")
+var minEntries = flag.Int("min", 10000, "Refuse to write a snapshot with fewer entries, since it is not a full registry.")
+
+// Generates the compressed snapshot embedded in the package.
+func main() {
+	flag.Parse()
+
+	dir, err := ioutil.TempDir("", "oui")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var names []string
+	for _, src := range strings.Split(*sources, ",") {
+		if !strings.HasPrefix(src, "http") {
+			names = append(names, src)
+			continue
+		}
+		log.Println("Downloading: " + src)
+		name := filepath.Join(dir, filepath.Base(src))
+		if err := download(src, name); err != nil {
+			log.Fatalf("Error downloading:%s", err.Error())
+		}
+		names = append(names, name)
+	}
+
+	db, err := oui.OpenStaticFiles(names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if n := db.Len(); n < *minEntries {
+		log.Fatalf("Only %d entries read, expected at least %d. Not writing snapshot.", n, *minEntries)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	zw, err := gzip.NewWriterLevel(f, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := oui.WriteSnapshot(db, zw); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote database generated at %s to %s", db.Generated(), *out)
+}
+
+// Download the URL to a file.
+func download(url, name string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
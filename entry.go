@@ -6,20 +6,45 @@ import (
 
 //go:generate: ffjson -nodecoder $(GOFILE)
 
+// Registry is the name of an IEEE registry that assigns addresses.
+type Registry string
+
+// The IEEE registries.
+const (
+	MAL Registry = "MA-L" // MAC Address Block Large (24 bit OUI)
+	MAM Registry = "MA-M" // MAC Address Block Medium (28 bit)
+	MAS Registry = "MA-S" // MAC Address Block Small (36 bit)
+	IAB Registry = "IAB"  // Individual Address Block (36 bit)
+	CID Registry = "CID"  // Company ID (24 bit)
+)
+
 // A database Entry the represents the data in the oui database.
 // Local and Multicast
+// Block is set for MA-M and MA-S entries, which are assigned
+// a smaller part of the OUI given in Prefix.
+// Registry and ShortName are only set if the source contains them.
+// Layer is set by a LayeredDB to the name of the layer the entry was found in,
+// and in merge conflicts to the name of the source.
 type Entry struct {
 	Manufacturer string       `json:"manufacturer"`
+	ShortName    string       `json:"short_name,omitempty"`
 	Address      []string     `json:"address"`
 	Prefix       HardwareAddr `json:"prefix"`
+	Block        *Block       `json:"block,omitempty"`
+	Registry     Registry     `json:"registry,omitempty"`
 	Country      string       `json:"country,omitempty"`
 	Local        bool         `json:"local,omitempty"`
 	Multicast    bool         `json:"multicast,omitempty"`
+	Layer        string       `json:"layer,omitempty"`
 }
 
 // Returns a formatted string representation of the entry
 func (e Entry) String() string {
-	t := []string{"Prefix: " + e.Prefix.String(), "Manufacturer: " + e.Manufacturer}
+	t := []string{"Prefix: " + e.Prefix.String()}
+	if e.Block != nil {
+		t = append(t, "Block: "+e.Block.String())
+	}
+	t = append(t, "Manufacturer: "+e.Manufacturer)
 	if len(e.Address) > 0 {
 		a := strings.Join(e.Address, "\n\t")
 		t = append(t, "Address:", "\t"+a)
@@ -30,5 +55,8 @@ func (e Entry) String() string {
 	if e.Multicast {
 		t = append(t, "* Multicast")
 	}
+	if e.Layer != "" {
+		t = append(t, "Layer: "+e.Layer)
+	}
 	return strings.Join(t, "\n")
 }
@@ -27,7 +27,13 @@ func (mj *Entry) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	_ = err
 	buf.WriteString(`{ "manufacturer":`)
 	fflib.WriteJsonString(buf, string(mj.Manufacturer))
-	buf.WriteString(`,"address":`)
+	buf.WriteByte(',')
+	if len(mj.ShortName) != 0 {
+		buf.WriteString(`"short_name":`)
+		fflib.WriteJsonString(buf, string(mj.ShortName))
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"address":`)
 	if mj.Address != nil {
 		buf.WriteString(`[`)
 		for i, v := range mj.Address {
@@ -51,6 +57,26 @@ func (mj *Entry) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 	}
 
 	buf.WriteByte(',')
+	if mj.Block != nil {
+		if true {
+			buf.WriteString(`"block":`)
+
+			{
+				obj, err = mj.Block.MarshalJSON()
+				if err != nil {
+					return err
+				}
+				buf.Write(obj)
+			}
+
+			buf.WriteByte(',')
+		}
+	}
+	if len(mj.Registry) != 0 {
+		buf.WriteString(`"registry":`)
+		fflib.WriteJsonString(buf, string(mj.Registry))
+		buf.WriteByte(',')
+	}
 	if len(mj.Country) != 0 {
 		buf.WriteString(`"country":`)
 		fflib.WriteJsonString(buf, string(mj.Country))
@@ -72,6 +98,11 @@ func (mj *Entry) MarshalJSONBuf(buf fflib.EncodingBuffer) error {
 		}
 		buf.WriteByte(',')
 	}
+	if len(mj.Layer) != 0 {
+		buf.WriteString(`"layer":`)
+		fflib.WriteJsonString(buf, string(mj.Layer))
+		buf.WriteByte(',')
+	}
 	buf.Rewind(1)
 	buf.WriteByte('}')
 	return nil
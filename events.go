@@ -0,0 +1,169 @@
+package oui
+
+import (
+	"time"
+)
+
+// EventKind is the kind of change to a dynamic database.
+type EventKind int
+
+const (
+	// EventReload is sent when the content is replaced by one of the Update functions.
+	EventReload EventKind = iota
+	// EventUpdate is sent when a single entry is added or updated.
+	EventUpdate
+	// EventDelete is sent when a single entry is deleted.
+	EventDelete
+	// EventDropped is sent when events were dropped because the subscriber
+	// didn't keep up. Anything derived from the database should be refreshed
+	// when it is received, since the database then contains the dropped changes.
+	EventDropped
+)
+
+// String returns the name of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventReload:
+		return "reload"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	case EventDropped:
+		return "dropped"
+	}
+	return "unknown"
+}
+
+// Event describes a change to a dynamic database.
+type Event struct {
+	Kind EventKind
+
+	// For EventReload, the number of entries added, removed and changed,
+	// and the generation times before and after the reload.
+	Added, Removed, Changed    int
+	OldGenerated, NewGenerated time.Time
+
+	// For EventUpdate the new entry, and for EventDelete the deleted entry.
+	Entry *Entry
+	// For EventUpdate the entry that was replaced, or nil if the entry was added.
+	Previous *Entry
+
+	// For EventDropped, the number of events dropped.
+	Dropped int
+}
+
+// Subscription delivers events about changes to a dynamic database.
+// Create one with the Subscribe method of the database.
+type Subscription struct {
+	// C delivers the events. It is closed when the subscription is closed.
+	C <-chan Event
+
+	c  chan Event
+	db *updateableDB
+
+	// Events dropped and not yet reported by an EventDropped event.
+	// Protected by the database write lock.
+	dropped int
+}
+
+// Subscribe returns a subscription to changes of the database.
+// Events are sent after the change is visible to queries.
+// Sending never blocks the writer: if buffer events are waiting to be received,
+// further events are dropped. The channel has room for one more event,
+// which is reserved for EventDropped, so an EventDropped event is queued
+// as soon as an event is dropped, without waiting for later changes.
+// If more events are dropped while that event is queued, it is still received
+// after those changes were made, so refreshing when it is received is enough.
+// The events are counted in the next EventDropped event.
+// Close the subscription when it is no longer needed.
+func (o *updateableDB) Subscribe(buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	c := make(chan Event, buffer+1)
+	s := &Subscription{C: c, c: c, db: o}
+	o.mu.Lock()
+	o.subs = append(o.subs, s)
+	o.mu.Unlock()
+	return s
+}
+
+// Close stops the subscription and closes the channel.
+// Events not yet received are discarded.
+// Calling Close more than once has no effect.
+func (s *Subscription) Close() {
+	o := s.db
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, sub := range o.subs {
+		if sub == s {
+			o.subs = append(o.subs[:i:i], o.subs[i+1:]...)
+			close(s.c)
+			return
+		}
+	}
+}
+
+// Send events without blocking.
+// Must be called with the database write lock held.
+// Only the writer sends, so the channel cannot fill up
+// between checking the length and sending.
+func (s *Subscription) send(events []Event) {
+	for _, ev := range events {
+		if s.dropped > 0 {
+			s.sendDropped()
+		}
+		// The last slot is reserved for EventDropped.
+		if s.dropped == 0 && len(s.c) < cap(s.c)-1 {
+			s.c <- ev
+			continue
+		}
+		s.dropped++
+		s.sendDropped()
+	}
+}
+
+// Queue an EventDropped event if there is room.
+// If there isn't, an EventDropped event is already queued.
+func (s *Subscription) sendDropped() {
+	select {
+	case s.c <- Event{Kind: EventDropped, Dropped: s.dropped}:
+		s.dropped = 0
+	default:
+	}
+}
+
+// Return the reload event for replacing the content of old with new.
+func reloadEvent(old, new *dbState) Event {
+	ev := Event{Kind: EventReload, OldGenerated: old.dbTime, NewGenerated: new.dbTime}
+	for k, a := range old.oui {
+		if b, ok := new.oui[k]; !ok {
+			ev.Removed++
+		} else if !entryEqual(&a, &b) {
+			ev.Changed++
+		}
+	}
+	for k, a := range old.blocks {
+		if b, ok := new.blocks[k]; !ok {
+			ev.Removed++
+		} else if !entryEqual(&a, &b) {
+			ev.Changed++
+		}
+	}
+	ev.Added = new.ouiDB.len() - (old.ouiDB.len() - ev.Removed)
+	return ev
+}
+
+// entryEqual returns whether two entries have the same content.
+func entryEqual(a, b *Entry) bool {
+	if a.Manufacturer != b.Manufacturer || a.ShortName != b.ShortName ||
+		a.Prefix != b.Prefix || a.Registry != b.Registry || a.Country != b.Country ||
+		a.Local != b.Local || a.Multicast != b.Multicast {
+		return false
+	}
+	if (a.Block == nil) != (b.Block == nil) || a.Block != nil && *a.Block != *b.Block {
+		return false
+	}
+	return stringsEqual(a.Address, b.Address)
+}
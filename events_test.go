@@ -0,0 +1,130 @@
+package oui
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// Receive the events that are queued.
+func queued(s *Subscription) []Event {
+	var res []Event
+	for {
+		select {
+		case ev := <-s.C:
+			res = append(res, ev)
+		default:
+			return res
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	db := testDynamic(t)
+	sub := db.Subscribe(10)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	old, _ := db.LookUp(hw)
+
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Changed"})
+	db.DeleteEntry(hw)
+	var buf bytes.Buffer
+	if err := WriteSnapshot(testDB(t), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	evs := queued(sub)
+	if len(evs) != 3 {
+		t.Fatalf("want 3 events, got %v", evs)
+	}
+	if ev := evs[0]; ev.Kind != EventUpdate || ev.Entry.Manufacturer != "Changed" || ev.Previous == nil || ev.Previous.Manufacturer != old.Manufacturer {
+		t.Errorf("unexpected update event %+v", ev)
+	}
+	if ev := evs[1]; ev.Kind != EventDelete || ev.Entry.Manufacturer != "Changed" {
+		t.Errorf("unexpected delete event %+v", ev)
+	}
+	if ev := evs[2]; ev.Kind != EventReload || ev.Added != 1 || ev.Removed != 0 || ev.Changed != 0 {
+		t.Errorf("unexpected reload event %+v", ev)
+	}
+
+	sub.Close()
+	sub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatal("channel not closed")
+	}
+	// Changes after Close are not sent.
+	db.DeleteEntry(hw)
+}
+
+func TestSubscribeDropped(t *testing.T) {
+	db := testDynamic(t)
+	sub := db.Subscribe(2)
+	defer sub.Close()
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	for i := 0; i < 5; i++ {
+		db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Changed"})
+	}
+
+	// The drop is reported without further changes.
+	evs := queued(sub)
+	if len(evs) != 3 {
+		t.Fatalf("want 3 events, got %v", evs)
+	}
+	if evs[0].Kind != EventUpdate || evs[1].Kind != EventUpdate {
+		t.Fatalf("unexpected events %v", evs)
+	}
+	if evs[2].Kind != EventDropped || evs[2].Dropped != 1 {
+		t.Fatalf("want EventDropped, got %+v", evs[2])
+	}
+
+	// The events dropped while the EventDropped event was queued
+	// are counted in the next one.
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Again"})
+	evs = queued(sub)
+	if len(evs) != 2 || evs[0].Kind != EventDropped || evs[0].Dropped != 2 || evs[1].Kind != EventUpdate {
+		t.Fatalf("unexpected events %v", evs)
+	}
+}
+
+func TestSubscribeConcurrent(t *testing.T) {
+	db := testDynamic(t)
+	sub := db.Subscribe(4)
+	hw := HardwareAddr{0x00, 0x60, 0x92}
+	const writes = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	received := 0
+	go func() {
+		defer wg.Done()
+		for ev := range sub.C {
+			switch ev.Kind {
+			case EventUpdate:
+				received++
+			case EventDropped:
+				received += ev.Dropped
+			}
+		}
+	}()
+	for i := 0; i < writes; i++ {
+		db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Changed"})
+	}
+	// Flush the count of events dropped while an EventDropped event was queued.
+	for {
+		s := db.(*updateableDB)
+		s.mu.Lock()
+		pending := sub.dropped
+		s.mu.Unlock()
+		if pending == 0 {
+			break
+		}
+		db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Changed"})
+	}
+	sub.Close()
+	wg.Wait()
+	if received < writes {
+		t.Fatalf("%d of %d events received or reported as dropped", received, writes)
+	}
+}
@@ -0,0 +1,114 @@
+package oui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Export will write the entire database to w in the given format.
+// Entries are written sorted by prefix, so the output is deterministic.
+// All formats except FormatAuto are supported.
+// Only the text and snapshot formats contain the generation time.
+func Export(db OuiDB, w io.Writer, f Format) error {
+	bw := bufio.NewWriter(w)
+	entries := db.entries()
+	var err error
+	switch f {
+	case FormatText:
+		err = writeText(bw, entries, db)
+	case FormatCSV:
+		err = writeCSV(bw, entries)
+	case FormatManuf:
+		err = writeManuf(bw, entries)
+	case FormatNmap:
+		err = writeNmap(bw, entries)
+	case FormatJSONL:
+		err = writeJSONL(bw, entries)
+	case FormatSnapshot:
+		return WriteSnapshot(db, w)
+	default:
+		return fmt.Errorf("export: unsupported format %v", f)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Write entries in the IEEE text format used by oui.txt.
+// Blocks are written with their assigned range like in mam.txt and oui36.txt.
+func writeText(w *bufio.Writer, entries []Entry, db OuiDB) error {
+	if t := db.Generated(); !t.IsZero() {
+		fmt.Fprintf(w, "  Generated: %s\n\n", t.Format(generatedFormat))
+	}
+	w.WriteString("OUI/MA-L\t\t\tOrganization\ncompany_id\t\t\tOrganization\n\t\t\t\tAddress\n\n")
+	for _, e := range entries {
+		p := e.Prefix
+		fmt.Fprintf(w, "%02X-%02X-%02X   (hex)\t\t%s\n", p[0], p[1], p[2], e.Manufacturer)
+		if e.Block != nil {
+			lo, hi := blockRange24(*e.Block)
+			fmt.Fprintf(w, "%06X-%06X     (base 16)\t\t%s\n", lo, hi, e.Manufacturer)
+		} else {
+			fmt.Fprintf(w, "%02X%02X%02X     (base 16)\t\t%s\n", p[0], p[1], p[2], e.Manufacturer)
+		}
+		for _, a := range e.Address {
+			fmt.Fprintf(w, "\t\t\t\t%s\n", a)
+		}
+		if _, err := w.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockRange24 returns the first and last value of the last
+// 24 bits of the addresses in the block.
+func blockRange24(b Block) (lo, hi uint32) {
+	lo = uint32(b.Addr[3])<<16 | uint32(b.Addr[4])<<8 | uint32(b.Addr[5])
+	return lo, lo | (1<<uint(48-b.Bits) - 1)
+}
+
+// prefixHex returns the prefix of the entry as hex digits,
+// with as many digits as needed for the prefix length.
+func prefixHex(e *Entry) string {
+	if e.Block == nil {
+		return fmt.Sprintf("%02X%02X%02X", e.Prefix[0], e.Prefix[1], e.Prefix[2])
+	}
+	a := e.Block.Addr
+	s := fmt.Sprintf("%02X%02X%02X%02X%02X%02X", a[0], a[1], a[2], a[3], a[4], a[5])
+	return s[:(e.Block.Bits+3)/4]
+}
+
+// registry returns the registry of the entry.
+// If it isn't known it is guessed from the prefix length.
+func registry(e *Entry) Registry {
+	if e.Registry != "" {
+		return e.Registry
+	}
+	if e.Block == nil {
+		return MAL
+	}
+	if e.Block.Bits <= 28 {
+		return MAM
+	}
+	return MAS
+}
+
+// shortName returns the short name of the entry.
+// If it isn't known the first word of the manufacturer is used.
+func shortName(e *Entry) string {
+	if e.ShortName != "" {
+		return e.ShortName
+	}
+	f := strings.Fields(e.Manufacturer)
+	if len(f) == 0 {
+		return ""
+	}
+	s := strings.TrimRight(f[0], ",.;")
+	if len(s) > 12 {
+		s = s[:12]
+	}
+	return s
+}
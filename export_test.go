@@ -0,0 +1,116 @@
+package oui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Return a database with OUI, MA-M and MA-S entries.
+func testDB(t testing.TB) StaticDB {
+	const in = `  Generated: Thu, 29 Jan 2015 00:39:43 -0500
+
+  00-60-92   (hex)		MICRO/SYS, INC.
+  006092     (base 16)		MICRO/SYS, INC.
+				3447 OCEAN VIEW BLVD.
+				GLENDALE CA 91208
+				UNITED STATES
+
+  00-60-94   (hex)		IBM Corp
+  006094     (base 16)		IBM Corp
+				3039 E Cornwallis Road
+				Research Triangle Park NC 27709-2195
+				UNITED STATES
+
+  70-B3-D5   (hex)		Tiny IoT
+  F2C000-F2CFFF     (base 16)		Tiny IoT
+				Other Street
+				DE
+
+  70-B3-D5   (hex)		Big Umbrella
+  F00000-FFFFFF     (base 16)		Big Umbrella
+				Some Street
+				US
+`
+	db, err := OpenStatic(strings.NewReader(in), WithFormat(FormatText), WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	db := testDB(t)
+	want := db.entries()
+	for _, f := range []Format{FormatText, FormatCSV, FormatManuf, FormatNmap, FormatJSONL, FormatSnapshot} {
+		var buf bytes.Buffer
+		if err := Export(db, &buf, f); err != nil {
+			t.Fatalf("%v: %v", f, err)
+		}
+		s := NewScanner(&buf)
+		var got []Entry
+		for s.Scan() {
+			got = append(got, s.Entry())
+		}
+		if err := s.Err(); err != nil {
+			t.Fatalf("%v: %v", f, err)
+		}
+		if s.Format() != f {
+			t.Errorf("%v: detected as %v", f, s.Format())
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%v: got %d entries, want %d", f, len(got), len(want))
+		}
+		for i := range want {
+			g, w := &got[i], &want[i]
+			if entryPrefix(g) != entryPrefix(w) || g.Manufacturer != w.Manufacturer {
+				t.Errorf("%v: got %s %q, want %s %q", f, entryPrefix(g), g.Manufacturer, entryPrefix(w), w.Manufacturer)
+			}
+			// The manuf and nmap formats have no addresses.
+			if f == FormatManuf || f == FormatNmap {
+				continue
+			}
+			if len(g.Address) == 0 {
+				t.Errorf("%v: %s has no address", f, entryPrefix(g))
+			}
+		}
+		gen := s.Generated()
+		if f == FormatText || f == FormatSnapshot {
+			if !gen.Equal(db.Generated()) {
+				t.Errorf("%v: generated %v, want %v", f, gen, db.Generated())
+			}
+		} else if !gen.IsZero() {
+			t.Errorf("%v: unexpected generation time %v", f, gen)
+		}
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	const in = `{"manufacturer":"Tiny IoT","address":["Other Street","DE"],"prefix":"70:b3:d5","block":"70:b3:d5:f2:c0:00/36","country":"DE"}
+
+{"manufacturer":"Local","prefix":"02:00:00"}
+not json
+`
+	var report ParseReport
+	db, err := Open(strings.NewReader(in), WithFormat(FormatJSONL), WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 2 || len(report.Warnings) != 1 || report.Warnings[0].Line != 4 {
+		t.Fatalf("got %d entries and warnings %v", db.Len(), report.Warnings)
+	}
+	e, err := db.Query("70:b3:d5:f2:c1:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Tiny IoT" || e.Block == nil || e.Country != "DE" || len(e.Address) != 2 {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	e, err = db.Query("02:00:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Local {
+		t.Fatal("local bit not set")
+	}
+}
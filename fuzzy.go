@@ -0,0 +1,359 @@
+package oui
+
+import (
+	"container/heap"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SearchResult is an entry found by Search, with a score
+// between 0 and 1 telling how well it matches.
+type SearchResult struct {
+	Entry Entry   `json:"entry"`
+	Score float64 `json:"score"`
+}
+
+// The fields of an entry that are searched, and their weight.
+const (
+	fieldName = iota
+	fieldAddress
+)
+
+var fieldWeight = [...]float64{fieldName: 1, fieldAddress: 0.6}
+
+// An occurrence of a word in an entry.
+type posting struct {
+	entry int32
+	field uint8
+}
+
+// An index of the words in the manufacturer names and addresses
+// for typo tolerant searches. Like nameIndex it is built on first use
+// and never updated.
+type fuzzyIndex struct {
+	once    sync.Once
+	entries []Entry
+	// The number of words in the name of each entry.
+	nameWords []uint8
+	// All words, sorted, and where they occur.
+	words    []string
+	postings [][]posting
+	// The words containing each trigram.
+	trigrams map[string][]int32
+}
+
+// Split text into lower case words of letters and digits.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Return the trigrams of a word, with the beginning and end marked.
+func wordTrigrams(w string) []string {
+	r := []rune("^" + w + "$")
+	if len(r) < 3 {
+		return []string{string(r)}
+	}
+	res := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		res = append(res, string(r[i:i+3]))
+	}
+	return res
+}
+
+// Build the index from the entries.
+func (x *fuzzyIndex) build(entries []Entry) {
+	x.entries = entries
+	x.nameWords = make([]uint8, len(entries))
+	ids := make(map[string]int32)
+	var postings [][]posting
+	add := func(w string, p posting) {
+		id, ok := ids[w]
+		if !ok {
+			id = int32(len(postings))
+			ids[w] = id
+			postings = append(postings, nil)
+		}
+		// Words are added entry by entry, so duplicates are adjacent.
+		if l := postings[id]; len(l) > 0 && l[len(l)-1] == p {
+			return
+		}
+		postings[id] = append(postings[id], p)
+	}
+	for i, e := range entries {
+		words := splitWords(e.Manufacturer)
+		if len(words) > 255 {
+			words = words[:255]
+		}
+		x.nameWords[i] = uint8(len(words))
+		for _, w := range words {
+			add(w, posting{entry: int32(i), field: fieldName})
+		}
+		for _, a := range e.Address {
+			for _, w := range splitWords(a) {
+				add(w, posting{entry: int32(i), field: fieldAddress})
+			}
+		}
+	}
+
+	// Sort the words, so prefixes can be found.
+	x.words = make([]string, 0, len(ids))
+	for w := range ids {
+		x.words = append(x.words, w)
+	}
+	sort.Strings(x.words)
+	x.postings = make([][]posting, len(x.words))
+	x.trigrams = make(map[string][]int32)
+	for i, w := range x.words {
+		x.postings[i] = postings[ids[w]]
+		for _, t := range wordTrigrams(w) {
+			x.trigrams[t] = append(x.trigrams[t], int32(i))
+		}
+	}
+}
+
+// The number of typos allowed in a word.
+func maxTypos(w string) int {
+	switch n := len([]rune(w)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// Return the words similar to w, with the similarity between 0 and 1.
+// Words starting with w are included, so the last word can be incomplete.
+func (x *fuzzyIndex) similar(w string) map[int32]float64 {
+	res := make(map[int32]float64)
+	// Words starting with w, including w.
+	for i := sort.SearchStrings(x.words, w); i < len(x.words) && strings.HasPrefix(x.words[i], w); i++ {
+		if x.words[i] == w {
+			res[int32(i)] = 1
+			continue
+		}
+		res[int32(i)] = 0.8 + 0.1*float64(len(w))/float64(len(x.words[i]))
+	}
+
+	// Words with typos share trigrams with w.
+	typos := maxTypos(w)
+	if typos == 0 {
+		return res
+	}
+	tri := wordTrigrams(w)
+	shared := make(map[int32]int)
+	for _, t := range tri {
+		for _, id := range x.trigrams[t] {
+			shared[id]++
+		}
+	}
+	// Each typo changes at most 3 trigrams, or 4 for a transposition.
+	need := len(tri) - 4*typos
+	if need < 1 {
+		need = 1
+	}
+	for id, n := range shared {
+		if n < need {
+			continue
+		}
+		if _, ok := res[id]; ok {
+			continue
+		}
+		d := editDistance(w, x.words[id], typos)
+		if d > typos {
+			continue
+		}
+		res[id] = 0.7 - 0.2*float64(d-1)
+	}
+	return res
+}
+
+// Search the index for entries matching the query.
+// The entries must be sorted by prefix.
+func (x *fuzzyIndex) search(entries func() []Entry, query string, limit int) []SearchResult {
+	x.once.Do(func() {
+		x.build(entries())
+	})
+	words := splitWords(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// Common words occur in thousands of entries,
+	// so scores are kept in slices rather than maps.
+	var (
+		sum       = make([]float64, len(x.entries))
+		nameWords = make([]uint8, len(x.entries))
+		best      = make([]float64, len(x.entries))
+		nameHit   = make([]bool, len(x.entries))
+		found     []int32
+		touched   []int32
+	)
+	for _, w := range words {
+		touched = touched[:0]
+		for id, sim := range x.similar(w) {
+			for _, p := range x.postings[id] {
+				if best[p.entry] == 0 {
+					touched = append(touched, p.entry)
+				}
+				if s := sim * fieldWeight[p.field]; s > best[p.entry] {
+					best[p.entry] = s
+				}
+				if p.field == fieldName {
+					nameHit[p.entry] = true
+				}
+			}
+		}
+		for _, e := range touched {
+			if sum[e] == 0 {
+				found = append(found, e)
+			}
+			sum[e] += best[e]
+			if nameHit[e] {
+				nameWords[e]++
+			}
+			best[e], nameHit[e] = 0, false
+		}
+	}
+
+	// Keep the best results in a heap with the worst on top.
+	h := &resultHeap{}
+	for _, e := range found {
+		// Mostly how well the query matches, and a bit
+		// how much of the name the query covers.
+		s := 0.9 * sum[e] / float64(len(words))
+		if n := x.nameWords[e]; n > 0 {
+			cover := float64(nameWords[e]) / float64(n)
+			if cover > 1 {
+				cover = 1
+			}
+			s += 0.1 * cover
+		}
+		r := SearchResult{Entry: x.entries[e], Score: s}
+		if limit <= 0 || h.Len() < limit {
+			heap.Push(h, r)
+			continue
+		}
+		if resultBetter(&r, &(*h)[0]) {
+			(*h)[0] = r
+			heap.Fix(h, 0)
+		}
+	}
+	res := make([]SearchResult, h.Len())
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = heap.Pop(h).(SearchResult)
+	}
+	return res
+}
+
+// Reports whether a should be listed before b.
+// Results with the same score are sorted by manufacturer and prefix.
+func resultBetter(a, b *SearchResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Entry.Manufacturer != b.Entry.Manufacturer {
+		return a.Entry.Manufacturer < b.Entry.Manufacturer
+	}
+	return entryLess(&a.Entry, &b.Entry)
+}
+
+// A heap of results with the worst result first.
+type resultHeap []SearchResult
+
+func (h resultHeap) Len() int            { return len(h) }
+func (h resultHeap) Less(i, j int) bool  { return resultBetter(&h[j], &h[i]) }
+func (h resultHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(SearchResult)) }
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions needed to change a into b.
+// If the distance is larger than max, max+1 is returned.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+	// Three rows are needed for transpositions.
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d := prev[j-1] + cost
+			if v := prev[j] + 1; v < d {
+				d = v
+			}
+			if v := cur[j-1] + 1; v < d {
+				d = v
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if v := prev2[j-2] + 1; v < d {
+					d = v
+				}
+			}
+			cur[j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if d := prev[len(rb)]; d <= max {
+		return d
+	}
+	return max + 1
+}
+
+// Search returns the entries where the manufacturer or address best match
+// the query, ranked by score.
+func (o staticDB) Search(query string, limit int) []SearchResult {
+	return o.ouiDB.fuzzy.search(o.entries, query, limit)
+}
+
+// Search returns the entries where the manufacturer or address best match
+// the query, ranked by score.
+// The index is kept for the current content, and is rebuilt on the first search
+// after the content has changed.
+func (o *updateableDB) Search(query string, limit int) []SearchResult {
+	s := o.load()
+	return s.fuzzy.search(s.sorted, query, limit)
+}
+
+// Search returns the entries where the manufacturer or address best match
+// the query, ranked by score.
+// The index is built on first search.
+func (db *mappedDB) Search(query string, limit int) []SearchResult {
+	return db.fuzzy.search(db.entries, query, limit)
+}
+
+// Search returns the entries where the manufacturer or address best match
+// the query, ranked by score.
+// Entries hidden by an entry of a layer before them are not included.
+// The index is kept until a layer is replaced or updated.
+func (db *layeredDB) Search(query string, limit int) []SearchResult {
+	c := db.current()
+	return c.fuzzy.search(c.sorted, query, limit)
+}
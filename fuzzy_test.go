@@ -0,0 +1,84 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	const in = "000001 Cisco Systems, Inc\n000002 Cisco-Linksys\n000003 Apple, Inc.\n000004 Applied Materials\n000005 Hewlett Packard\n"
+	db, err := Open(strings.NewReader(in), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		query, want string
+	}{
+		{"cisco systems", "Cisco Systems, Inc"},
+		{"cisoc sys", "Cisco Systems, Inc"},
+		{"csico systmes", "Cisco Systems, Inc"},
+		{"appl", "Apple, Inc."},
+		{"aplied", "Applied Materials"},
+		{"hewlet pakard", "Hewlett Packard"},
+	} {
+		res := db.Search(tc.query, 1)
+		if len(res) != 1 || res[0].Entry.Manufacturer != tc.want {
+			t.Errorf("%q: got %v, want %q", tc.query, res, tc.want)
+			continue
+		}
+		if res[0].Score <= 0 || res[0].Score > 1 {
+			t.Errorf("%q: score %v out of range", tc.query, res[0].Score)
+		}
+	}
+
+	res := db.Search("appl", 0)
+	if len(res) != 2 || res[0].Score <= res[1].Score {
+		t.Fatalf("results not ranked: %v", res)
+	}
+	if res := db.Search("xyzzy", 0); len(res) != 0 {
+		t.Fatalf("unexpected results %v", res)
+	}
+	if res := db.Search(" ,. ", 0); res != nil {
+		t.Fatalf("unexpected results %v", res)
+	}
+
+	// The index follows updates.
+	hw := HardwareAddr{0x00, 0x00, 0x06}
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Zyxel Communications"})
+	if res := db.Search("zyxell", 1); len(res) != 1 || res[0].Entry.Prefix != hw {
+		t.Fatalf("update not seen: %v", res)
+	}
+}
+
+func TestSearchAddress(t *testing.T) {
+	db := testDB(t)
+	res := db.Search("glendale", 0)
+	if len(res) != 1 || res[0].Entry.Manufacturer != "MICRO/SYS, INC." {
+		t.Fatalf("unexpected results %v", res)
+	}
+	// Matches in the name rank above matches in the address.
+	name := db.Search("ibm", 1)
+	if len(name) != 1 || name[0].Score <= res[0].Score {
+		t.Fatalf("name match %v not ranked above address match %v", name, res)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"cisco", "cisco", 2, 0},
+		{"cisco", "cisoc", 2, 1},
+		{"cisco", "csico", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3},
+		{"abc", "xyz", 1, 2},
+		{"abc", "abcdef", 2, 3},
+	} {
+		if got := editDistance(tc.a, tc.b, tc.max); got != tc.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tc.a, tc.b, tc.max, got, tc.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package oui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -44,6 +45,93 @@ func (h HardwareAddr) Multicast() bool {
 	return (h[0] & 1) > 0
 }
 
+// Block is an address block that is longer than the 24 bit OUI.
+// These are assigned from the MA-M (28 bit) and MA-S/OUI-36 (36 bit)
+// registries, where each block is a sub-range of a 24 bit prefix.
+// Addr contains the first address of the block, so all bits
+// after the first Bits bits are zero.
+type Block struct {
+	Addr MAC
+	Bits int
+}
+
+// NewBlock returns the block with the given number of prefix bits
+// that contains the address. Bits after the prefix are cleared.
+func NewBlock(addr MAC, bits int) Block {
+	return Block{Addr: maskAddr(addr, bits), Bits: bits}
+}
+
+// OUI returns the 24 bit prefix the block belongs to.
+func (b Block) OUI() HardwareAddr {
+	return HardwareAddr{b.Addr[0], b.Addr[1], b.Addr[2]}
+}
+
+// Contains returns true if the address is within the block.
+func (b Block) Contains(addr MAC) bool {
+	return maskAddr(addr, b.Bits) == b.Addr
+}
+
+// String returns the block as "xx:xx:xx:xx:xx:xx/bits", where the address
+// is the first address of the block.
+func (b Block) String() string {
+	a := b.Addr
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x/%d", a[0], a[1], a[2], a[3], a[4], a[5], b.Bits)
+}
+
+// This function will return the block as a quoted string.
+func (b Block) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + b.String() + `"`), nil
+}
+
+// This function will read the block from a quoted string.
+func (b *Block) UnmarshalJSON(in []byte) error {
+	n, err := ParseBlock(strings.Trim(string(in), `" `))
+	if err != nil {
+		return err
+	}
+	*b = *n
+	return nil
+}
+
+// ParseBlock will parse a block written as an address and a prefix length,
+// for instance "70:B3:D5:F2:C0:00/36".
+// The address is parsed like ParseMac, and bits after the prefix are ignored.
+func ParseBlock(s string) (*Block, error) {
+	i := strings.LastIndex(s, "/")
+	if i < 0 {
+		return nil, ErrInvalidMac{Reason: "Block has no prefix length", Mac: s}
+	}
+	bits, err := strconv.Atoi(s[i+1:])
+	if err != nil || bits < 24 || bits > 48 {
+		return nil, ErrInvalidMac{Reason: "Block prefix length must be between 24 and 48", Mac: s}
+	}
+	addr, n, err := parseAddr(s[:i])
+	if err != nil {
+		return nil, err
+	}
+	if n*8 < bits {
+		return nil, ErrInvalidMac{Reason: fmt.Sprintf("Block address is too short for a /%d prefix", bits), Mac: s}
+	}
+	b := NewBlock(addr, bits)
+	return &b, nil
+}
+
+// maskAddr will clear all bits after the first 'bits' bits of the address.
+func maskAddr(a MAC, bits int) MAC {
+	for i := range a {
+		switch {
+		case bits >= 8:
+			bits -= 8
+		case bits <= 0:
+			a[i] = 0
+		default:
+			a[i] &= byte(0xff << uint(8-bits))
+			bits = 0
+		}
+	}
+	return a
+}
+
 // This error will be returned by ParseMac
 // if the Mac address cannot be decoded.
 type ErrInvalidMac struct {
@@ -59,18 +147,63 @@ func (e ErrInvalidMac) Error() string {
 // ParseMac will parse a string Mac address and return the first 3 entries.
 // It will attempt to find a separator, ':' and '-' supported.
 // If none of these are matched, it will assume there is none.
+// Use ParseFullMAC to parse the full address.
 func ParseMac(mac string) (*HardwareAddr, error) {
-	// Attempt to find a separator, ':' and '-' supported.
+	s, err := splitMac(mac)
+	if err != nil {
+		return nil, err
+	}
+	hw := HardwareAddr{}
+	for i, p := range s {
+		if i >= 3 {
+			break
+		}
+		b, err := parseElement(mac, i, p)
+		if err != nil {
+			return nil, err
+		}
+		hw[i] = b
+	}
+	return &hw, nil
+}
+
+// parseAddr will parse as many elements of the mac address as possible.
+// The first 3 elements are required, like ParseMac, but parsing will stop
+// silently at the first invalid element after these.
+// The number of parsed elements is returned.
+func parseAddr(mac string) (addr MAC, n int, err error) {
+	s, err := splitMac(mac)
+	if err != nil {
+		return addr, 0, err
+	}
+	for i, p := range s {
+		if i >= len(addr) {
+			break
+		}
+		b, err := parseElement(mac, i, p)
+		if err != nil {
+			if i >= 3 {
+				break
+			}
+			return addr, 0, err
+		}
+		addr[i] = b
+		n++
+	}
+	return addr, n, nil
+}
+
+// splitMac will split a mac address into its elements.
+// It will attempt to find a separator, ':' and '-' supported.
+// If none of these are matched, it will assume there is none.
+func splitMac(mac string) ([]string, error) {
 	if len(mac) < 6 {
 		return nil, ErrInvalidMac{Reason: "Mac address too short. Should be at least 6 characters", Mac: mac}
 	}
-	var separator *byte
 	var s []string
 
 	if mac[2] == ':' || mac[2] == '-' {
-		b := mac[2]
-		separator = &b
-		s = strings.Split(mac, string(*separator))
+		s = strings.Split(mac, string(mac[2]))
 	} else {
 		for i := 0; i < len(mac)-1; i += 2 {
 			s = append(s, mac[i:i+2])
@@ -79,20 +212,17 @@ func ParseMac(mac string) (*HardwareAddr, error) {
 	if len(s) < 3 {
 		return nil, ErrInvalidMac{Reason: "Unable to find at least 3 address elements", Mac: mac}
 	}
-	hw := HardwareAddr{}
-	for i, p := range s {
-		if i >= 3 {
-			break
-		}
-		if len(p) != 2 {
-			return nil, ErrInvalidMac{Reason: fmt.Sprintf("Address element %d (%s) is not 2 characters", i+1, p), Mac: mac}
-		}
-		var b byte
-		n, err := fmt.Sscanf(p, "%x", &b)
-		if n != 1 {
-			return nil, ErrInvalidMac{Reason: fmt.Sprintf("Address element %d (%s) cannot be parsed as hex value: %v", i+1, p, err), Mac: mac}
-		}
-		hw[i] = b
+	return s, nil
+}
+
+// parseElement will parse element i of a mac address as a hex value.
+func parseElement(mac string, i int, p string) (byte, error) {
+	if len(p) != 2 {
+		return 0, ErrInvalidMac{Reason: fmt.Sprintf("Address element %d (%s) is not 2 characters", i+1, p), Mac: mac}
 	}
-	return &hw, nil
+	b, err := strconv.ParseUint(p, 16, 8)
+	if err != nil {
+		return 0, ErrInvalidMac{Reason: fmt.Sprintf("Address element %d (%s) cannot be parsed as hex value", i+1, p), Mac: mac}
+	}
+	return byte(b), nil
 }
@@ -0,0 +1,109 @@
+package oui
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoVersion is returned when rolling back to a version
+// that isn't in the history.
+var ErrNoVersion = errors.New("version not found in history")
+
+// Version describes a version of the content of a dynamic database.
+// A new version is created every time the content is replaced by
+// one of the Update functions or by a rollback.
+// Changes made with UpdateEntry, DeleteEntry, DeleteBlock and batches
+// are part of the current version.
+type Version struct {
+	// ID identifies the version. The first version loaded is 1.
+	ID int `json:"id"`
+	// Generated is the generation time of the content.
+	Generated time.Time `json:"generated"`
+	// Source is the file names or URL the content was read from, if known.
+	Source string `json:"source,omitempty"`
+	// Loaded is the time the version became current.
+	Loaded time.Time `json:"loaded"`
+	// Entries is the number of entries.
+	Entries int `json:"entries"`
+	// Current is true for the version being served.
+	Current bool `json:"current"`
+}
+
+// WithHistory will keep the last n versions of a dynamic database
+// besides the current version, so the database can be rolled back.
+// It is given when opening the database. By default no history is kept.
+func WithHistory(n int) Option {
+	return func(o *options) {
+		o.history = n
+	}
+}
+
+// WithSource sets the name of the input, which is used in parse errors
+// and in the history of dynamic databases.
+// Files and URLs are named automatically.
+func WithSource(name string) Option {
+	return func(o *options) {
+		o.source = name
+	}
+}
+
+// Return the description of a state.
+func (s *dbState) describe() Version {
+	return Version{ID: s.version, Generated: s.dbTime, Source: s.source, Loaded: s.loaded, Entries: s.ouiDB.len()}
+}
+
+// Add the current state to the history when it is replaced.
+// Must be called with the write lock held.
+func (o *updateableDB) pushHistory(s *dbState) {
+	if o.keep <= 0 {
+		return
+	}
+	o.history = append(o.history, s)
+	if len(o.history) > o.keep {
+		o.history = append(o.history[:0:0], o.history[len(o.history)-o.keep:]...)
+	}
+}
+
+// History returns the versions kept in the history followed
+// by the current version, oldest first.
+func (o *updateableDB) History() []Version {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	res := make([]Version, 0, len(o.history)+1)
+	for _, s := range o.history {
+		res = append(res, s.describe())
+	}
+	cur := o.load().describe()
+	cur.Current = true
+	return append(res, cur)
+}
+
+// Rollback atomically replaces the content with the version with the given ID.
+// The content becomes a new version with a new ID, and keeps the source
+// and generation time of the version rolled back to.
+// The current version is added to the history, so the rollback can be undone.
+// If the version isn't in the history, ErrNoVersion is returned,
+// and nothing is changed.
+func (o *updateableDB) Rollback(id int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, h := range o.history {
+		if h.version != id {
+			continue
+		}
+		old := o.load()
+		o.history = append(o.history[:i:i], o.history[i+1:]...)
+		o.pushHistory(old)
+		s := *h
+		o.versions++
+		s.version = o.versions
+		s.loaded = time.Now()
+		var events []Event
+		if len(o.subs) > 0 {
+			events = []Event{reloadEvent(old, &s)}
+		}
+		o.publish(&s, events)
+		return nil
+	}
+	return ErrNoVersion
+}
@@ -0,0 +1,143 @@
+package oui
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Return a snapshot of the content of db.
+func snapshotOf(t testing.TB, db OuiDB) []byte {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHistory(t *testing.T) {
+	db := testDynamic(t, WithHistory(2), WithSource("first"))
+	full := snapshotOf(t, db)
+	small, err := OpenStatic(strings.NewReader("000001 One\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"second", "third", "fourth"} {
+		if err := Update(db, bytes.NewReader(snapshotOf(t, small)), WithSource(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the last 2 versions are kept besides the current.
+	h := db.History()
+	if len(h) != 3 {
+		t.Fatalf("want 3 versions, got %+v", h)
+	}
+	for i, want := range []struct {
+		id      int
+		source  string
+		current bool
+	}{{2, "second", false}, {3, "third", false}, {4, "fourth", true}} {
+		if h[i].ID != want.id || h[i].Source != want.source || h[i].Current != want.current || h[i].Entries != 1 {
+			t.Errorf("version %d: got %+v", i, h[i])
+		}
+	}
+
+	// A failed rollback doesn't publish a new state.
+	before := db.(*updateableDB).load()
+	if err := db.Rollback(1); err != ErrNoVersion {
+		t.Fatalf("want ErrNoVersion, got %v", err)
+	}
+	if db.(*updateableDB).load() != before {
+		t.Fatal("failed rollback replaced the state")
+	}
+
+	// Roll back to the full content, and undo it again.
+	if err := Update(db, bytes.NewReader(full), WithSource("full")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(db, bytes.NewReader(snapshotOf(t, small)), WithSource("small")); err != nil {
+		t.Fatal(err)
+	}
+	h = db.History()
+	fullID := h[len(h)-2].ID
+	sub := db.Subscribe(4)
+	defer sub.Close()
+	if err := db.Rollback(fullID); err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != testDB(t).Len() {
+		t.Fatalf("rolled back to %d entries", db.Len())
+	}
+	if ev := <-sub.C; ev.Kind != EventReload || ev.Removed != 1 {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	// The rollback is a new version, so the IDs stay in order.
+	h = db.History()
+	for i := 1; i < len(h); i++ {
+		if h[i].ID <= h[i-1].ID {
+			t.Fatalf("IDs out of order: %+v", h)
+		}
+	}
+	if cur := h[len(h)-1]; cur.ID == fullID || cur.Source != "full" || !cur.Current {
+		t.Fatalf("unexpected current version %+v", cur)
+	}
+	if prev := h[len(h)-2]; prev.Source != "small" {
+		t.Fatalf("replaced version not kept: %+v", h)
+	}
+	if err := db.Rollback(h[len(h)-2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 1 {
+		t.Fatalf("undo rolled back to %d entries", db.Len())
+	}
+}
+
+func TestHistoryDisabled(t *testing.T) {
+	db := testDynamic(t)
+	if err := Update(db, bytes.NewReader(snapshotOf(t, db))); err != nil {
+		t.Fatal(err)
+	}
+	if h := db.History(); len(h) != 1 || !h[0].Current {
+		t.Fatalf("want only the current version, got %+v", h)
+	}
+}
+
+func TestRollbackConcurrent(t *testing.T) {
+	db := testDynamic(t, WithHistory(1))
+	full := db.Len()
+	small, err := OpenStatic(strings.NewReader("000001 One\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(db, bytes.NewReader(snapshotOf(t, small))); err != nil {
+		t.Fatal(err)
+	}
+
+	var stop int32
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				if n := db.Len(); n != 1 && n != full {
+					t.Errorf("saw %d entries", n)
+					return
+				}
+				db.History()
+			}
+		}()
+	}
+	// Swap between the two versions.
+	for i := 0; i < 200; i++ {
+		h := db.History()
+		if err := db.Rollback(h[0].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+}
@@ -0,0 +1,226 @@
+package oui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed HTTP requests are retried.
+// Requests are retried on network errors and on "5xx" and
+// "429 Too Many Requests" responses.
+// The delay between attempts starts at Backoff and is doubled
+// for each attempt, but will never exceed MaxBackoff if it is set.
+type RetryPolicy struct {
+	// Attempts is the maximum number of requests made, including the first.
+	Attempts int
+	// Backoff is the delay before the first retry.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between attempts. Zero means no maximum.
+	MaxBackoff time.Duration
+}
+
+// WithHTTPClient sets the client used for HTTP requests.
+// This allows you to set timeouts, proxies and TLS configuration.
+// If no client is given http.DefaultClient is used, which has no timeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithContext sets the context of HTTP requests.
+// Cancelling the context will abort the download and any retries.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithRetry sets the policy for retrying failed HTTP requests.
+// By default requests are not retried.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = p
+	}
+}
+
+// WithMaxSize sets the maximum number of bytes read from a HTTP response body.
+// If the body is larger ErrTooLarge is returned.
+// Zero means no limit, which is the default.
+func WithMaxSize(n int64) Option {
+	return func(o *options) {
+		o.maxSize = n
+	}
+}
+
+// ErrTooLarge is returned if a HTTP response body is larger
+// than allowed by the WithMaxSize option.
+var ErrTooLarge = errors.New("response body too large")
+
+// A reader that returns ErrTooLarge if more than n bytes are read.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// HTTPError is returned when a HTTP download is rejected,
+// either because of the response status or because the content type
+// shows the response isn't a registry, for instance an error page.
+type HTTPError struct {
+	URL         string
+	StatusCode  int
+	Status      string
+	ContentType string
+	Reason      string
+}
+
+// Error returns a string representation of the error.
+func (e *HTTPError) Error() string {
+	return "download of '" + e.URL + "' failed: " + e.Reason + " (" + e.Status + ")"
+}
+
+// ErrNoEntries is returned when a download contains no entries.
+var ErrNoEntries = errors.New("no entries found")
+
+// checkResponse returns an error if the response is not a successful
+// response that could contain a registry.
+func checkResponse(url string, resp *http.Response) error {
+	ct := resp.Header.Get("Content-Type")
+	e := &HTTPError{URL: url, StatusCode: resp.StatusCode, Status: resp.Status, ContentType: ct}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		e.Reason = "unexpected status"
+		return e
+	}
+	if mt, _, err := mime.ParseMediaType(ct); err == nil && (mt == "text/html" || mt == "application/xhtml+xml") {
+		e.Reason = "unexpected content type " + mt
+		return e
+	}
+	return nil
+}
+
+// Validators of a HTTP response, used for conditional requests.
+type httpValidators struct {
+	url          string
+	etag         string
+	lastModified string
+}
+
+// Returned by scanHttp if the server reports the content hasn't changed.
+var errNotModified = errors.New("not modified")
+
+// Returns true if the response should be retried.
+func retryStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests
+}
+
+// Send the request with the client and retry policy of the options.
+func fetch(req *http.Request, o *options) (*http.Response, error) {
+	client := o.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req = req.WithContext(ctx)
+	backoff := o.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt >= o.retry.Attempts || (err == nil && !retryStatus(resp.StatusCode)) {
+			return resp, err
+		}
+		if err == nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if o.retry.MaxBackoff > 0 && backoff > o.retry.MaxBackoff {
+			backoff = o.retry.MaxBackoff
+		}
+	}
+}
+
+// Download the URL and read the content into the database.
+// A *HTTPError is returned if the response isn't successful, and
+// ErrNoEntries is returned if no entries were found.
+// If validators from a previous download of the same URL are given,
+// a conditional request is made, and errNotModified is returned if
+// the content hasn't changed.
+// The validators of the response are returned.
+func scanHttp(url string, db ouiDB, o *options, prev *httpValidators) (*time.Time, *httpValidators, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prev != nil && prev.url == url {
+		if prev.etag != "" {
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		if prev.lastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.lastModified)
+		}
+	}
+	resp, err := fetch(req, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, prev, errNotModified
+	}
+	if err := checkResponse(url, resp); err != nil {
+		return nil, nil, err
+	}
+	if o.maxSize > 0 && resp.ContentLength > o.maxSize {
+		return nil, nil, ErrTooLarge
+	}
+	v := &httpValidators{
+		url:          url,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}
+	ho := *o
+	ho.source = url
+	ho.hint = compressionFromEncoding(resp.Header.Get("Content-Encoding"))
+	if ho.hint == CompressionAuto {
+		ho.hint = compressionFromName(req.URL.Path)
+	}
+	var body io.Reader = resp.Body
+	lr := &limitReader{r: resp.Body, n: o.maxSize}
+	if o.maxSize > 0 {
+		body = lr
+	}
+	t, err := scan(body, db, &ho)
+	// Parsers may stop at read errors, so check the limit afterwards.
+	if lr.n < 0 {
+		return nil, nil, ErrTooLarge
+	}
+	if err == nil && db.len() == 0 {
+		err = ErrNoEntries
+	}
+	return t, v, err
+}
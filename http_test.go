@@ -0,0 +1,202 @@
+package oui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const httpContent = "000001 One\n000002 Two\n"
+
+// A test server returning content with validators,
+// which records the headers of the requests.
+type testServer struct {
+	mu       sync.Mutex
+	content  string
+	etag     string
+	modified string
+	requests []http.Header
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Header.Clone())
+	if r.Header.Get("If-None-Match") == s.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("ETag", s.etag)
+	w.Header().Set("Last-Modified", s.modified)
+	w.Write([]byte(s.content))
+}
+
+// Return the headers of the last request.
+func (s *testServer) last() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func TestHttpNotModified(t *testing.T) {
+	ts := &testServer{content: httpContent, etag: `"v1"`, modified: "Thu, 29 Jan 2015 05:39:43 GMT"}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	db, err := OpenHttp(srv.URL, WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := ts.last(); h.Get("If-None-Match") != "" || h.Get("If-Modified-Since") != "" {
+		t.Fatalf("first request was conditional: %v", h)
+	}
+
+	// The content hasn't changed.
+	before := db.(*updateableDB).load()
+	modified, err := UpdateHttpModified(db, srv.URL, WithFormat(FormatNmap))
+	if err != nil || modified {
+		t.Fatalf("want (false, nil), got (%v, %v)", modified, err)
+	}
+	h := ts.last()
+	if h.Get("If-None-Match") != `"v1"` || h.Get("If-Modified-Since") != ts.modified {
+		t.Fatalf("validators not sent: %v", h)
+	}
+	if db.(*updateableDB).load() != before {
+		t.Fatal("content replaced although it wasn't modified")
+	}
+
+	// The content has changed.
+	ts.mu.Lock()
+	ts.content, ts.etag = "000003 Three\n", `"v2"`
+	ts.mu.Unlock()
+	modified, err = UpdateHttpModified(db, srv.URL, WithFormat(FormatNmap))
+	if err != nil || !modified {
+		t.Fatalf("want (true, nil), got (%v, %v)", modified, err)
+	}
+	if e, err := db.Query("00:00:03"); err != nil || e.Manufacturer != "Three" || db.Len() != 1 {
+		t.Fatalf("content not updated: %v %v", e, err)
+	}
+
+	// Validators are only sent to the same URL.
+	if _, err := UpdateHttpModified(db, srv.URL+"/other", WithFormat(FormatNmap)); err != nil {
+		t.Fatal(err)
+	}
+	if h := ts.last(); h.Get("If-None-Match") != "" {
+		t.Fatalf("validators sent to another URL: %v", h)
+	}
+}
+
+func TestHttpRetry(t *testing.T) {
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(httpContent))
+	}))
+	defer srv.Close()
+
+	db, err := OpenHttp(srv.URL, WithFormat(FormatNmap), WithRetry(RetryPolicy{Attempts: 2, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 2 || atomic.LoadInt32(&n) != 2 {
+		t.Fatalf("got %d entries after %d requests", db.Len(), n)
+	}
+}
+
+func TestHttpRetryCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	// Cancelling the context stops waiting for the next attempt.
+	start := time.Now()
+	_, err := OpenHttp(srv.URL, WithContext(ctx), WithRetry(RetryPolicy{Attempts: 5, Backoff: time.Hour}))
+	if err != context.Canceled {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	if atomic.LoadInt32(&n) != 1 || time.Since(start) > time.Minute {
+		t.Fatalf("retried %d times", n)
+	}
+}
+
+func TestHttpMaxSize(t *testing.T) {
+	body := strings.Repeat("000001 One\n", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chunked" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write([]byte(body))
+			return
+		}
+		// Flushing sends the body without a Content-Length,
+		// so the size is only known when it has been read.
+		w.Write([]byte(body[:10]))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(body[10:]))
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/", "/chunked"} {
+		if _, err := OpenHttp(srv.URL+path, WithFormat(FormatNmap), WithMaxSize(100)); err != ErrTooLarge {
+			t.Errorf("%s: want ErrTooLarge, got %v", path, err)
+		}
+		if db, err := OpenHttp(srv.URL+path, WithFormat(FormatNmap), WithMaxSize(int64(len(body)))); err != nil || db.Len() != 1 {
+			t.Errorf("%s: body within the limit rejected: %v", path, err)
+		}
+	}
+}
+
+func TestHttpRejected(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(httpContent))
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>Maintenance</body></html>\n"))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	db, err := OpenHttp(srv.URL+"/ok", WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := db.(*updateableDB).load()
+	for path, status := range map[string]int{"/unavailable": http.StatusServiceUnavailable, "/html": http.StatusOK} {
+		err := UpdateHttp(db, srv.URL+path, WithFormat(FormatNmap))
+		herr, ok := err.(*HTTPError)
+		if !ok {
+			t.Errorf("%s: want *HTTPError, got %v", path, err)
+			continue
+		}
+		if herr.StatusCode != status || herr.URL != srv.URL+path {
+			t.Errorf("%s: unexpected error %+v", path, herr)
+		}
+	}
+	if err := UpdateHttp(db, srv.URL+"/empty", WithFormat(FormatNmap)); err != ErrNoEntries {
+		t.Errorf("want ErrNoEntries, got %v", err)
+	}
+	if db.(*updateableDB).load() != before || db.Len() != 2 {
+		t.Fatal("previous content not kept")
+	}
+}
@@ -0,0 +1,69 @@
+package oui
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Reads JSON Lines with one JSON encoded Entry on each line.
+// The files contain no generation time, so the generation time is always nil.
+type jsonlReader struct {
+	scanner *lineScanner
+	p       *parseState
+}
+
+// Create a reader of the JSON Lines format.
+func newJSONLReader(in io.Reader, p *parseState) *jsonlReader {
+	return &jsonlReader{scanner: newLineScanner(in), p: p}
+}
+
+// Return the generation time, which is always nil.
+func (r *jsonlReader) generated() *time.Time {
+	return nil
+}
+
+// Return the next entry.
+func (r *jsonlReader) next() (*Entry, error) {
+	scanner, p := r.scanner, r.p
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(text), &e); err != nil {
+			if err := p.problem(scanner.line, text, err.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if e.Block != nil {
+			e.Prefix = e.Block.OUI()
+		}
+		e.Local = e.Prefix.Local()
+		e.Multicast = e.Prefix.Multicast()
+		return &e, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Write entries as JSON Lines.
+func writeJSONL(w *bufio.Writer, entries []Entry) error {
+	for i := range entries {
+		j, err := entries[i].MarshalJSON()
+		if err != nil {
+			return err
+		}
+		w.Write(j)
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package oui
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoLayer is returned when a layer with the given name doesn't exist.
+var ErrNoLayer = errors.New("layer not found")
+
+// Layer is a named database in a LayeredDB.
+type Layer struct {
+	Name string
+	DB   OuiDB
+}
+
+// LayeredDB is a database that stacks several databases,
+// for instance local overrides on top of the IEEE registry
+// on top of a Wireshark 'manuf' file.
+//
+// Lookups search the layers in order, and the first layer that contains
+// an entry for the address answers, with its most specific entry.
+// So an OUI entry in a layer hides blocks of the same OUI in the layers below.
+// The Layer field of the returned entries is set to the name of the layer.
+//
+// Each layer can be updated on its own, either by opening it as a DynamicDB
+// and updating it, or by replacing it with SetLayer.
+// The generation time is the latest generation time of the layers.
+type LayeredDB interface {
+	OuiDB
+
+	// Layers returns the layers, in the order they are searched.
+	Layers() []Layer
+
+	// Layer returns the database of the layer with the given name,
+	// or nil if there is no such layer.
+	Layer(name string) OuiDB
+
+	// SetLayer atomically replaces the database of the layer with the given name.
+	// If there is no such layer, ErrNoLayer is returned.
+	SetLayer(name string, db OuiDB) error
+}
+
+// A layered database.
+// The layers are published as an immutable []Layer,
+// so lookups never lock.
+type layeredDB struct {
+	layers atomic.Value
+	mu     sync.Mutex
+	// The *layerCache of the current content of the layers.
+	cache atomic.Value
+}
+
+// The combined entries and indexes of the layers, built on first use.
+// A cache is only used while the layers have the content it was created
+// for, so replacing or updating a layer creates a new cache.
+type layerCache struct {
+	layers []Layer
+	// The content ID of each layer.
+	ids []interface{}
+
+	once    sync.Once
+	entries []Entry
+
+	// Index by manufacturer, built on first search.
+	idx nameIndex
+	// Index of words for fuzzy searches, built on first search.
+	fuzzy fuzzyIndex
+}
+
+// Check we implement the interfaces we promise
+var _ LayeredDB = &layeredDB{}
+
+// NewLayered returns a database searching the given layers in order,
+// so the first layer has the highest precedence.
+func NewLayered(layers ...Layer) LayeredDB {
+	db := &layeredDB{}
+	db.layers.Store(append([]Layer(nil), layers...))
+	return db
+}
+
+// Return the current layers.
+func (db *layeredDB) load() []Layer {
+	return db.layers.Load().([]Layer)
+}
+
+// Layers returns the layers, in the order they are searched.
+func (db *layeredDB) Layers() []Layer {
+	return append([]Layer(nil), db.load()...)
+}
+
+// Layer returns the database of the layer with the given name,
+// or nil if there is no such layer.
+func (db *layeredDB) Layer(name string) OuiDB {
+	for _, l := range db.load() {
+		if l.Name == name {
+			return l.DB
+		}
+	}
+	return nil
+}
+
+// SetLayer atomically replaces the database of the layer with the given name.
+// If there is no such layer, ErrNoLayer is returned.
+func (db *layeredDB) SetLayer(name string, d OuiDB) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	layers := db.Layers()
+	for i := range layers {
+		if layers[i].Name == name {
+			layers[i].DB = d
+			db.layers.Store(layers)
+			return nil
+		}
+	}
+	return ErrNoLayer
+}
+
+// Search the layers in order with the lookup function.
+// The first entry found is returned with the layer name set.
+func (db *layeredDB) find(fn func(OuiDB) (*Entry, error)) (*Entry, error) {
+	for _, l := range db.load() {
+		e, err := fn(l.DB)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res := *e
+		res.Layer = l.Name
+		return &res, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Query the database for an entry based on the mac address
+// The most specific entry of the first layer with an entry will be returned.
+// If none are found ErrNotFound will be returned.
+func (db *layeredDB) Query(mac string) (*Entry, error) {
+	if _, _, err := parseAddr(mac); err != nil {
+		return nil, err
+	}
+	return db.find(func(d OuiDB) (*Entry, error) {
+		return d.Query(mac)
+	})
+}
+
+// Look up a hardware address and return the entry of the first layer with an entry.
+// If none are found ErrNotFound will be returned.
+func (db *layeredDB) LookUp(hw HardwareAddr) (*Entry, error) {
+	return db.find(func(d OuiDB) (*Entry, error) {
+		return d.LookUp(hw)
+	})
+}
+
+// Look up a full mac address and return the most specific entry of the first layer with an entry.
+// If none are found ErrNotFound will be returned.
+func (db *layeredDB) LookUpMAC(m MAC) (*Entry, error) {
+	return db.find(func(d OuiDB) (*Entry, error) {
+		return d.LookUpMAC(m)
+	})
+}
+
+// Get the latest generated time of the layers.
+func (db *layeredDB) Generated() time.Time {
+	var t time.Time
+	for _, l := range db.load() {
+		if g := l.DB.Generated(); g.After(t) {
+			t = g
+		}
+	}
+	return t
+}
+
+// The generation time is given by the layers.
+func (db *layeredDB) generatedAt(*time.Time) {}
+
+// Set an element in the first layer.
+func (db *layeredDB) set(hw HardwareAddr, e Entry) {
+	if layers := db.load(); len(layers) > 0 {
+		layers[0].DB.set(hw, e)
+	}
+}
+
+// Return the cache for the current content of the layers.
+func (db *layeredDB) current() *layerCache {
+	layers := db.load()
+	ids := make([]interface{}, len(layers))
+	for i, l := range layers {
+		ids[i] = l.DB.contentID()
+	}
+	if c, ok := db.cache.Load().(*layerCache); ok && sameIDs(c.ids, ids) {
+		return c
+	}
+	c := &layerCache{layers: layers, ids: ids}
+	db.cache.Store(c)
+	return c
+}
+
+// Reports whether the content IDs are the same.
+func sameIDs(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// The content ID changes whenever the content of a layer changes.
+func (db *layeredDB) contentID() interface{} {
+	return db.current()
+}
+
+// Return all entries sorted by prefix.
+// Entries hidden by an entry of a layer before them are not included.
+// The entries are shared, and must not be modified.
+func (db *layeredDB) entries() []Entry {
+	return db.current().sorted()
+}
+
+// Return all entries of the layers sorted by prefix,
+// combining them on first use.
+func (c *layerCache) sorted() []Entry {
+	c.once.Do(func() {
+		c.entries = combineLayers(c.layers)
+	})
+	return c.entries
+}
+
+// Return the entries of the layers sorted by prefix.
+// An entry is hidden if a layer before it has an entry containing
+// all of its addresses, since lookups will never return it.
+// So an OUI entry hides all entries of the OUI in the layers below,
+// and a block hides the same block and the smaller blocks inside it.
+func combineLayers(layers []Layer) []Entry {
+	var res []Entry
+	hiddenOui := make(map[HardwareAddr]bool)
+	hiddenBlock := make(map[Block]bool)
+	hidden := func(e *Entry) bool {
+		if hiddenOui[e.Prefix] {
+			return true
+		}
+		if e.Block == nil {
+			return false
+		}
+		for _, bits := range blockBits {
+			if bits <= e.Block.Bits && hiddenBlock[NewBlock(e.Block.Addr, bits)] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, l := range layers {
+		// Entries of a layer only hide entries of the layers below.
+		start := len(res)
+		for _, e := range l.DB.entries() {
+			if hidden(&e) {
+				continue
+			}
+			e.Layer = l.Name
+			res = append(res, e)
+		}
+		for _, e := range res[start:] {
+			if e.Block != nil {
+				hiddenBlock[NewBlock(e.Block.Addr, e.Block.Bits)] = true
+			} else {
+				hiddenOui[e.Prefix] = true
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return entryLess(&res[i], &res[j])
+	})
+	return res
+}
+
+// Len returns the number of entries.
+// The count is kept until the content of a layer changes.
+func (db *layeredDB) Len() int {
+	return len(db.entries())
+}
+
+// All returns an iterator over all entries sorted by prefix.
+// Entries hidden by an entry of a layer before them are not included.
+func (db *layeredDB) All() func(yield func(Entry) bool) {
+	return iterate(db.entries)
+}
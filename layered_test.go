@@ -0,0 +1,186 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+// Return a layered database with a dynamic local layer on top of testDB.
+func testLayered(t *testing.T) (LayeredDB, DynamicDB) {
+	local, err := Open(strings.NewReader("006092 Local Corp\n020000 Lab\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewLayered(Layer{Name: "local", DB: local}, Layer{Name: "ieee", DB: testDB(t)}), local
+}
+
+func TestLayered(t *testing.T) {
+	db, _ := testLayered(t)
+	e, err := db.Query("00:60:92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Local Corp" || e.Layer != "local" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	e, err = db.Query("00:60:94")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Layer != "ieee" {
+		t.Fatalf("unexpected entry %v", e)
+	}
+	// 00:60:92 is in both layers.
+	if n := db.Len(); n != testDB(t).Len()+1 {
+		t.Fatalf("got %d entries", n)
+	}
+	var layers []string
+	for e := range db.All() {
+		if e.Prefix == (HardwareAddr{0x00, 0x60, 0x92}) {
+			layers = append(layers, e.Layer)
+		}
+	}
+	if len(layers) != 1 || layers[0] != "local" {
+		t.Fatalf("unexpected entries for 00:60:92 from %v", layers)
+	}
+}
+
+func TestLayeredCache(t *testing.T) {
+	db, local := testLayered(t)
+	n := db.Len()
+	if db.Len() != n {
+		t.Fatal("count changed")
+	}
+	c := db.(*layeredDB).current()
+	if db.(*layeredDB).current() != c {
+		t.Fatal("cache not reused")
+	}
+
+	// Updating a layer invalidates the cache.
+	hw := HardwareAddr{0x02, 0x00, 0x01}
+	local.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Lab 2"})
+	if db.Len() != n+1 {
+		t.Fatalf("update of layer not seen, got %d entries", db.Len())
+	}
+
+	// Replacing a layer invalidates the cache.
+	empty, err := OpenStatic(strings.NewReader(""), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLayer("local", empty); err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != testDB(t).Len() {
+		t.Fatalf("replaced layer not seen, got %d entries", db.Len())
+	}
+	if err := db.SetLayer("missing", empty); err != ErrNoLayer {
+		t.Fatalf("want ErrNoLayer, got %v", err)
+	}
+
+	// Nested layered databases see changes of their layers.
+	outer := NewLayered(Layer{Name: "inner", DB: db})
+	n = outer.Len()
+	if err := db.SetLayer("local", local); err != nil {
+		t.Fatal(err)
+	}
+	if outer.Len() == n {
+		t.Fatal("change of nested layer not seen")
+	}
+}
+
+func TestLayeredSearchManufacturer(t *testing.T) {
+	db, local := testLayered(t)
+	if res := db.SearchManufacturer("local corp", MatchFold); len(res) != 1 || res[0].Layer != "local" {
+		t.Fatalf("unexpected result %v", res)
+	}
+	// The IEEE entry is hidden by the local entry.
+	if res := db.SearchManufacturer("MICRO/SYS, INC.", MatchExact); len(res) != 0 {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	hw := HardwareAddr{0x02, 0x00, 0x01}
+	local.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Local Corp"})
+	if res := db.SearchManufacturer("local", MatchPrefix); len(res) != 2 {
+		t.Fatalf("update of layer not seen: %v", res)
+	}
+	local.DeleteEntry(HardwareAddr{0x00, 0x60, 0x92})
+	if res := db.SearchManufacturer("MICRO/SYS, INC.", MatchExact); len(res) != 1 || res[0].Layer != "ieee" {
+		t.Fatalf("delete in layer not seen: %v", res)
+	}
+}
+
+func TestLayeredSearch(t *testing.T) {
+	db, local := testLayered(t)
+	res := db.Search("locl corp", 1)
+	if len(res) != 1 || res[0].Entry.Manufacturer != "Local Corp" || res[0].Entry.Layer != "local" {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	hw := HardwareAddr{0x02, 0x00, 0x01}
+	local.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Fuzzy Widgets"})
+	res = db.Search("fuzy widg", 0)
+	if len(res) != 1 || res[0].Entry.Prefix != hw {
+		t.Fatalf("update of layer not seen: %v", res)
+	}
+	empty, err := OpenStatic(strings.NewReader(""), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLayer("local", empty); err != nil {
+		t.Fatal(err)
+	}
+	if res := db.Search("fuzzy widgets", 0); len(res) != 0 {
+		t.Fatalf("replaced layer still searched: %v", res)
+	}
+}
+
+func TestLayeredHidden(t *testing.T) {
+	local, err := OpenStatic(strings.NewReader("70B3D5 Override\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewLayered(Layer{Name: "local", DB: local}, Layer{Name: "ieee", DB: testDB(t)})
+	e, err := db.Query("70:b3:d5:f2:c1:00")
+	if err != nil || e.Manufacturer != "Override" {
+		t.Fatalf("unexpected entry %v %v", e, err)
+	}
+	// The blocks below the OUI are never returned by lookups.
+	var got []string
+	for e := range db.All() {
+		got = append(got, entryPrefix(&e)+"="+e.Layer)
+	}
+	want := "00:60:92=ieee,00:60:94=ieee,70:b3:d5=local"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if n := db.Len(); n != 3 {
+		t.Fatalf("got %d entries", n)
+	}
+	if res := db.SearchManufacturer("Tiny IoT", MatchExact); len(res) != 0 {
+		t.Fatalf("hidden entry found: %v", res)
+	}
+	if res := db.Search("tiny iot", 0); len(res) != 0 {
+		t.Fatalf("hidden entry found: %v", res)
+	}
+
+	// A block only hides the blocks inside it, the OUI below is still used
+	// for the other addresses.
+	local, err = OpenStatic(strings.NewReader("70B3D5F Override\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ieee, err := OpenStatic(strings.NewReader("70B3D5 IEEE RA\n70B3D5F2C Tiny IoT\n70B3D5E Other\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = NewLayered(Layer{Name: "local", DB: local}, Layer{Name: "ieee", DB: ieee})
+	got = got[:0]
+	for e := range db.All() {
+		got = append(got, entryPrefix(&e)+"="+e.Layer)
+	}
+	want = "70:b3:d5=ieee,70:b3:d5:e0:00:00/28=ieee,70:b3:d5:f0:00:00/28=local"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Format is the file format of a registry.
+type Format int
+
+const (
+	// FormatAuto will detect the format from the content.
+	FormatAuto Format = iota
+	// FormatText is the IEEE text format used by oui.txt, mam.txt and oui36.txt.
+	FormatText
+	// FormatCSV is the IEEE CSV format used by oui.csv, mam.csv and oui36.csv.
+	FormatCSV
+	// FormatManuf is the Wireshark 'manuf' format.
+	FormatManuf
+	// FormatNmap is the nmap 'nmap-mac-prefixes' format.
+	FormatNmap
+	// FormatJSONL is JSON Lines with one JSON encoded Entry on each line.
+	FormatJSONL
+	// FormatSnapshot is the binary format written by WriteSnapshot.
+	FormatSnapshot
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+	case FormatText:
+		return "text"
+	case FormatCSV:
+		return "csv"
+	case FormatManuf:
+		return "manuf"
+	case FormatNmap:
+		return "nmap"
+	case FormatJSONL:
+		return "jsonl"
+	case FormatSnapshot:
+		return "snapshot"
+	}
+	return "unknown"
+}
+
+// An Option can be given when opening or updating a database.
+type Option func(*options)
+
+// The options given when opening or updating a database.
+type options struct {
+	format      Format
+	compression Compression
+
+	// Compression given by the file name or HTTP headers.
+	hint Compression
+
+	// HTTP options.
+	client  *http.Client
+	ctx     context.Context
+	retry   RetryPolicy
+	maxSize int64
+
+	// Update options.
+	policy UpdatePolicy
+
+	// The number of previous versions kept by dynamic databases.
+	history int
+
+	// Parse options.
+	strict bool
+	report *ParseReport
+	source string
+}
+
+// Return the options with all the supplied options applied.
+func getOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFormat sets the format of the input.
+// If the format isn't specified or FormatAuto is given,
+// the format is detected from the content.
+func WithFormat(f Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// The amount of input examined when detecting the format.
+const detectSize = 16 << 10
+
+var (
+	detectManuf = regexp.MustCompile(`^[0-9A-Fa-f]{2}[:-][0-9A-Fa-f]{2}[:-][0-9A-Fa-f]{2}\S*\t`)
+	detectNmap  = regexp.MustCompile(`^[0-9A-Fa-f]{6,12}\s`)
+)
+
+// detectFormat will examine the beginning of the input and return the format.
+// If the format cannot be recognized, FormatText is returned.
+func detectFormat(r *bufio.Reader) Format {
+	b, _ := r.Peek(detectSize)
+	if bytes.HasPrefix(b, []byte(snapshotMagic)) {
+		return FormatSnapshot
+	}
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(bytes.TrimSpace(line)) == 0 || line[0] == '#' {
+			continue
+		}
+		switch {
+		case line[0] == '{':
+			return FormatJSONL
+		case bytes.HasPrefix(bytes.TrimPrefix(line, []byte("\ufeff")), []byte(csvRegistry+",")):
+			return FormatCSV
+		case bytes.Contains(line, []byte("(hex)")), bytes.Contains(line, []byte("Generated: ")):
+			return FormatText
+		case detectManuf.Match(line):
+			return FormatManuf
+		case detectNmap.Match(line):
+			return FormatNmap
+		}
+	}
+	return FormatText
+}
+
+// Read the content in the format given by the options into the database.
+// Compressed content is decompressed while it is read.
+func scan(in io.Reader, db ouiDB, o *options) (*time.Time, error) {
+	s := newScanner(in, o)
+	for s.Scan() {
+		e := s.Entry()
+		db.set(e.Prefix, e)
+	}
+	var generated *time.Time
+	if s.r != nil {
+		generated = s.r.generated()
+	}
+	return generated, s.Err()
+}
+
+// Read all the named files into the database.
+// The latest generation time found in the files is returned.
+func scanFiles(names []string, db ouiDB, o *options) (*time.Time, error) {
+	var generated *time.Time
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return generated, err
+		}
+		fo := *o
+		fo.hint = compressionFromName(name)
+		fo.source = name
+		t, err := scan(file, db, &fo)
+		file.Close()
+		if t != nil && (generated == nil || t.After(*generated)) {
+			generated = t
+		}
+		if err != nil {
+			return generated, err
+		}
+	}
+	return generated, nil
+}
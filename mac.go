@@ -0,0 +1,101 @@
+package oui
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MAC is a full 48 bit mac address.
+// An easy way to get a mac address is to use the ParseFullMAC function.
+// The address is in transmission bit order.
+type MAC [6]byte
+
+// String returns a hex string of the address.
+// This will be as "aa:bb:cc:dd:ee:ff" where elements are separated by ':'
+// and written in transmission bit order
+func (m MAC) String() string {
+	return fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", m[0], m[1], m[2], m[3], m[4], m[5])
+}
+
+// This function will return the address as a quoted hex string.
+func (m MAC) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.String() + `"`), nil
+}
+
+// This function will read the address from a quoted hex string.
+func (m *MAC) UnmarshalJSON(in []byte) error {
+	n, err := ParseFullMAC(strings.Trim(string(in), `" `))
+	if err != nil {
+		return err
+	}
+	*m = *n
+	return nil
+}
+
+// OUI returns the first 24 bits of the address.
+func (m MAC) OUI() HardwareAddr {
+	return HardwareAddr{m[0], m[1], m[2]}
+}
+
+// NIC returns the last 24 bits of the address,
+// which are assigned by the owner of the OUI.
+func (m MAC) NIC() [3]byte {
+	return [3]byte{m[3], m[4], m[5]}
+}
+
+// Local returns true if the address is in the
+// "locally administered" segment.
+func (m MAC) Local() bool {
+	return (m[0] & 2) > 0
+}
+
+// Multicast returns true if the address is in the
+// multicast segment.
+func (m MAC) Multicast() bool {
+	return (m[0] & 1) > 0
+}
+
+// Net returns the address as a net.HardwareAddr.
+func (m MAC) Net() net.HardwareAddr {
+	return net.HardwareAddr(m[:])
+}
+
+// NetMAC returns the address of a net.HardwareAddr.
+// Only 48 bit addresses are supported.
+func NetMAC(hw net.HardwareAddr) (*MAC, error) {
+	if len(hw) != len(MAC{}) {
+		return nil, ErrInvalidMac{Reason: fmt.Sprintf("Address is %d bytes, must be %d", len(hw), len(MAC{})), Mac: hw.String()}
+	}
+	var m MAC
+	copy(m[:], hw)
+	return &m, nil
+}
+
+// ParseFullMAC will parse a full string Mac address.
+// It will attempt to find a separator, ':' and '-' supported.
+// If none of these are matched, it will assume there is none.
+// Unlike ParseMac all 6 elements must be present, and an address
+// without separators must have an even number of characters.
+func ParseFullMAC(mac string) (*MAC, error) {
+	s, err := splitMac(mac)
+	if err != nil {
+		return nil, err
+	}
+	// splitMac ignores a trailing character without separators.
+	if mac[2] != ':' && mac[2] != '-' && len(mac)%2 != 0 {
+		return nil, ErrInvalidMac{Reason: "Mac address without separators must have an even number of characters", Mac: mac}
+	}
+	if len(s) != len(MAC{}) {
+		return nil, ErrInvalidMac{Reason: fmt.Sprintf("Found %d address elements, must be %d", len(s), len(MAC{})), Mac: mac}
+	}
+	var m MAC
+	for i, p := range s {
+		b, err := parseElement(mac, i, p)
+		if err != nil {
+			return nil, err
+		}
+		m[i] = b
+	}
+	return &m, nil
+}
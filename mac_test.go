@@ -0,0 +1,47 @@
+package oui
+
+import "testing"
+
+func TestParseFullMAC(t *testing.T) {
+	want := MAC{0x00, 0x60, 0x93, 0x98, 0x02, 0x01}
+	for _, s := range []string{"00-60-93-98-02-01", "00:60:93:98:02:01", "006093980201"} {
+		m, err := ParseFullMAC(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		if *m != want {
+			t.Errorf("%q: got %v, want %v", s, m, want)
+		}
+	}
+
+	for _, s := range []string{
+		"006093980201f",
+		"00609398020",
+		"00609398020100",
+		"00-60-93-98-02",
+		"00-60-93-98-02-01-",
+		"00-60-93-98-02-0g",
+		"0060",
+	} {
+		if m, err := ParseFullMAC(s); err == nil {
+			t.Errorf("%q: accepted as %v", s, m)
+		} else if _, ok := err.(ErrInvalidMac); !ok {
+			t.Errorf("%q: unexpected error type %T", s, err)
+		}
+	}
+}
+
+func TestParseMacOddLength(t *testing.T) {
+	// ParseMac only uses the first 3 elements,
+	// so a trailing character is ignored as it always has been.
+	for _, s := range []string{"0060931", "006093"} {
+		hw, err := ParseMac(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if *hw != (HardwareAddr{0x00, 0x60, 0x93}) {
+			t.Errorf("%q: got %v", s, hw)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package oui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reads a Wireshark 'manuf' file.
+// Each line contains a prefix, a short name and optionally a long name
+// separated by tabs, for instance:
+//
+//	00:00:0C	Cisco	Cisco Systems, Inc
+//	00:1B:C5:00:00:00/36	Converging	Converging Systems Inc.
+//
+// Only 24, 28 and 36 bit prefixes are read, other entries are skipped.
+// The files contain no generation time, so the generation time is always nil.
+type manufReader struct {
+	scanner *lineScanner
+	p       *parseState
+}
+
+// Create a reader of the manuf format.
+func newManufReader(in io.Reader, p *parseState) *manufReader {
+	return &manufReader{scanner: newLineScanner(in), p: p}
+}
+
+// Return the generation time, which is always nil.
+func (r *manufReader) generated() *time.Time {
+	return nil
+}
+
+// Return the next entry.
+func (r *manufReader) next() (*Entry, error) {
+	scanner, p := r.scanner, r.p
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		var fields []string
+		for _, f := range strings.Split(text, "\t") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) < 2 {
+			if err := p.problem(scanner.line, text, "missing name"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e, err := manufEntry(fields[0])
+		if err != nil {
+			if err := p.problem(scanner.line, text, err.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if e == nil {
+			continue
+		}
+		// Older files have the long name as a comment,
+		// either after the short name or in a field of its own.
+		short := fields[1]
+		if i := strings.Index(short, "#"); i >= 0 {
+			fields = append(fields[:1], strings.TrimSpace(short[:i]), strings.TrimSpace(short[i+1:]))
+			short = fields[1]
+		}
+		e.ShortName = short
+		e.Manufacturer = short
+		if len(fields) > 2 {
+			if long := strings.TrimSpace(strings.TrimPrefix(fields[2], "#")); long != "" {
+				e.Manufacturer = long
+			}
+		}
+		return e, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// manufEntry returns an entry with the prefix of a manuf line,
+// for instance "00:00:0C" or "00:1B:C5:00:00:00/36".
+// If the prefix length isn't supported, nil is returned.
+func manufEntry(s string) (*Entry, error) {
+	prefix, mask := s, ""
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		prefix, mask = s[:i], s[i+1:]
+	}
+	addr, n, err := parseAddr(prefix)
+	if err != nil {
+		return nil, err
+	}
+	bits := n * 8
+	if mask != "" {
+		bits, err = strconv.Atoi(mask)
+		if err != nil || bits > n*8 {
+			return nil, ErrInvalidMac{Reason: "Invalid prefix length", Mac: s}
+		}
+	}
+	if bits != 24 && bits != 28 && bits != 36 {
+		return nil, nil
+	}
+	e := Entry{Prefix: addr.OUI()}
+	if bits > 24 {
+		b := NewBlock(addr, bits)
+		e.Block = &b
+	}
+	e.Local = e.Prefix.Local()
+	e.Multicast = e.Prefix.Multicast()
+	return &e, nil
+}
+
+// Write entries in the Wireshark 'manuf' format.
+func writeManuf(w *bufio.Writer, entries []Entry) error {
+	for i := range entries {
+		e := &entries[i]
+		prefix := e.Prefix.String()
+		if e.Block != nil {
+			prefix = e.Block.String()
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", strings.ToUpper(prefix), shortName(e), e.Manufacturer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+const manufInput = `# Wireshark manuf sample
+00:00:0C	Cisco	Cisco Systems, Inc
+00:00:0D	Fibronic	# Fibronics Ltd.
+00:00:0E	Fujitsu                # Fujitsu Limited
+00:1B:C5:00:00:00/36	Converging	Converging Systems Inc.
+00:50:C2:00:00:00/28	IeeeRegi
+01:80:C2:00:00:00/48	Spanning-tree-(for-bridges)_00
+`
+
+func TestManuf(t *testing.T) {
+	s := NewScanner(strings.NewReader(manufInput))
+	var got []Entry
+	for s.Scan() {
+		got = append(got, s.Entry())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if f := s.Format(); f != FormatManuf {
+		t.Fatalf("detected format %v", f)
+	}
+	// The 48 bit entry is skipped.
+	if len(got) != 5 {
+		t.Fatalf("want 5 entries, got %d", len(got))
+	}
+	want := []struct{ prefix, short, name string }{
+		{"00:00:0c", "Cisco", "Cisco Systems, Inc"},
+		{"00:00:0d", "Fibronic", "Fibronics Ltd."},
+		{"00:00:0e", "Fujitsu", "Fujitsu Limited"},
+		{"00:1b:c5:00:00:00/36", "Converging", "Converging Systems Inc."},
+		{"00:50:c2:00:00:00/28", "IeeeRegi", "IeeeRegi"},
+	}
+	for i, w := range want {
+		e := got[i]
+		if p := entryPrefix(&e); p != w.prefix || e.ShortName != w.short || e.Manufacturer != w.name {
+			t.Errorf("entry %d: got %s %q %q, want %s %q %q", i, p, e.ShortName, e.Manufacturer, w.prefix, w.short, w.name)
+		}
+	}
+}
+
+func TestManufInvalidPrefix(t *testing.T) {
+	const in = "00:00:0C\tCisco\n00:00:0X\tBad\n00:1B:C5/36\tShort\n"
+	var report ParseReport
+	db, err := Open(strings.NewReader(in), WithFormat(FormatManuf), WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 1 || len(report.Warnings) != 2 {
+		t.Fatalf("got %d entries and warnings %v", db.Len(), report.Warnings)
+	}
+	if report.Warnings[0].Line != 2 || report.Warnings[1].Line != 3 {
+		t.Fatalf("unexpected lines in %v", report.Warnings)
+	}
+}
@@ -0,0 +1,373 @@
+package oui
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The index format is:
+//
+//	magic       [8]byte "OUIIDX\x00\x00"
+//	version     uint32
+//	count       uint32
+//	generated   int64, unix nanoseconds or 0 if unknown
+//	checksum    uint32 CRC32C of everything after the header
+//	reserved    uint32
+//	records     count records, sorted by key
+//	data        entry data
+//
+// Each record is 12 bytes:
+//
+//	key         uint64, the 48 bit prefix shifted left 8 bits, with the prefix length in the low 8 bits
+//	offset      uint32, offset of the entry data from the start of the data section
+//
+// The entry data is the Manufacturer, ShortName, Registry and Country
+// followed by the number of address lines and the address lines.
+// Numbers are written as uvarints and strings as an uvarint length followed by the bytes.
+// All fixed size values are little endian.
+
+// The magic bytes an index starts with.
+const indexMagic = "OUIIDX\x00\x00"
+
+// The current index version.
+const indexVersion = 1
+
+const (
+	indexHeaderSize = 32
+	indexRecordSize = 12
+)
+
+// ErrInvalidIndex is returned if an index is truncated,
+// fails the checksum or otherwise cannot be decoded.
+var ErrInvalidIndex = errors.New("invalid index")
+
+// ErrClosed is returned when querying a MappedDB that has been closed.
+var ErrClosed = errors.New("database closed")
+
+// indexKey returns the key of the first bits of the address.
+func indexKey(m MAC, bits int) uint64 {
+	m = maskAddr(m, bits)
+	var k uint64
+	for _, b := range m {
+		k = k<<8 | uint64(b)
+	}
+	return k<<8 | uint64(bits)
+}
+
+// entryKey returns the index key of an entry.
+func entryKey(e *Entry) uint64 {
+	if e.Block != nil {
+		return indexKey(e.Block.Addr, e.Block.Bits)
+	}
+	p := e.Prefix
+	return indexKey(MAC{p[0], p[1], p[2]}, 24)
+}
+
+// WriteIndex will write the database to w as an index that can be
+// opened as a memory mapped database with OpenMapped.
+func WriteIndex(db OuiDB, w io.Writer) error {
+	entries := db.entries()
+	sort.Slice(entries, func(i, j int) bool {
+		return entryKey(&entries[i]) < entryKey(&entries[j])
+	})
+
+	records := make([]byte, len(entries)*indexRecordSize)
+	var data []byte
+	var tmp [binary.MaxVarintLen64]byte
+	str := func(s string) {
+		data = append(data, tmp[:binary.PutUvarint(tmp[:], uint64(len(s)))]...)
+		data = append(data, s...)
+	}
+	for i := range entries {
+		e := &entries[i]
+		rec := records[i*indexRecordSize:]
+		binary.LittleEndian.PutUint64(rec, entryKey(e))
+		binary.LittleEndian.PutUint32(rec[8:], uint32(len(data)))
+		str(e.Manufacturer)
+		str(e.ShortName)
+		str(string(e.Registry))
+		str(e.Country)
+		data = append(data, tmp[:binary.PutUvarint(tmp[:], uint64(len(e.Address)))]...)
+		for _, a := range e.Address {
+			str(a)
+		}
+	}
+
+	var header [indexHeaderSize]byte
+	copy(header[:], indexMagic)
+	binary.LittleEndian.PutUint32(header[8:], indexVersion)
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(entries)))
+	if t := db.Generated(); !t.IsZero() {
+		binary.LittleEndian.PutUint64(header[16:], uint64(t.UnixNano()))
+	}
+	crc := crc32.Update(0, snapshotTable, records)
+	crc = crc32.Update(crc, snapshotTable, data)
+	binary.LittleEndian.PutUint32(header[24:], crc)
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header[:])
+	bw.Write(records)
+	bw.Write(data)
+	return bw.Flush()
+}
+
+// MappedDB is a read only database backed by a memory mapped index file.
+// The entries are not loaded into memory, but are read from the index
+// when they are looked up, so several processes using the same index
+// will share the memory through the page cache.
+// Entries returned are copies, so they remain valid after the database is closed.
+type MappedDB interface {
+	StaticDB
+
+	// Close will unmap the index.
+	// The database cannot be used after it has been closed.
+	Close() error
+}
+
+// A database backed by a memory mapped index.
+type mappedDB struct {
+	mu      sync.RWMutex
+	b       []byte
+	records []byte
+	data    []byte
+	count   int
+	dbTime  time.Time
+
+	// The raw database is built on first request.
+	rawOnce sync.Once
+	raw     map[[3]byte]Entry
+
+	// Index by manufacturer, built on first search.
+	idx nameIndex
+	// Index of words for fuzzy searches, built on first search.
+	fuzzy fuzzyIndex
+}
+
+// Check we implement the interfaces we promise
+var _ MappedDB = &mappedDB{}
+
+// OpenMapped will memory map an index written by WriteIndex
+// and return a read only database with the content.
+// On systems where memory mapping isn't supported the index is read into memory.
+// The RawDB() function is supported, but the map is built on the first call,
+// which will load all entries into memory.
+func OpenMapped(name string) (MappedDB, error) {
+	b, err := mmapFile(name)
+	if err != nil {
+		return nil, err
+	}
+	db, err := newMapped(b)
+	if err != nil {
+		munmapFile(b)
+		return nil, err
+	}
+	return db, nil
+}
+
+// Create a mapped database from the index content.
+func newMapped(b []byte) (*mappedDB, error) {
+	if len(b) < indexHeaderSize || string(b[:len(indexMagic)]) != indexMagic {
+		return nil, ErrInvalidIndex
+	}
+	if v := binary.LittleEndian.Uint32(b[8:]); v != indexVersion {
+		return nil, ErrInvalidIndex
+	}
+	count := int(binary.LittleEndian.Uint32(b[12:]))
+	if count > (len(b)-indexHeaderSize)/indexRecordSize {
+		return nil, ErrInvalidIndex
+	}
+	if crc32.Checksum(b[indexHeaderSize:], snapshotTable) != binary.LittleEndian.Uint32(b[24:]) {
+		return nil, ErrInvalidIndex
+	}
+	db := &mappedDB{
+		b:       b,
+		records: b[indexHeaderSize : indexHeaderSize+count*indexRecordSize],
+		data:    b[indexHeaderSize+count*indexRecordSize:],
+		count:   count,
+	}
+	if gen := int64(binary.LittleEndian.Uint64(b[16:])); gen != 0 {
+		db.dbTime = time.Unix(0, gen)
+	}
+	return db, nil
+}
+
+// Close will unmap the index.
+func (db *mappedDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.b == nil {
+		return nil
+	}
+	err := munmapFile(db.b)
+	db.b, db.records, db.data = nil, nil, nil
+	db.count = 0
+	return err
+}
+
+// Return the key of record i.
+func (db *mappedDB) key(i int) uint64 {
+	return binary.LittleEndian.Uint64(db.records[i*indexRecordSize:])
+}
+
+// Decode the entry of record i.
+func (db *mappedDB) entry(i int) (*Entry, error) {
+	rec := db.records[i*indexRecordSize:]
+	key := binary.LittleEndian.Uint64(rec)
+	off := binary.LittleEndian.Uint32(rec[8:])
+	if uint64(off) >= uint64(len(db.data)) {
+		return nil, ErrInvalidIndex
+	}
+	r := &snapshotReader{b: db.data[off:]}
+	str := func() string {
+		return string(r.bytes(r.uvarint()))
+	}
+	var e Entry
+	var m MAC
+	for j := range m {
+		m[j] = byte(key >> uint(8*(len(m)-j)))
+	}
+	e.Prefix = m.OUI()
+	if bits := int(key & 0xff); bits != 24 {
+		b := NewBlock(m, bits)
+		e.Block = &b
+	}
+	e.Manufacturer = str()
+	e.ShortName = str()
+	e.Registry = Registry(str())
+	e.Country = str()
+	if n := r.uvarint(); n > 0 && n <= uint64(len(r.b)) {
+		e.Address = make([]string, n)
+		for j := range e.Address {
+			e.Address[j] = str()
+		}
+	}
+	if r.err != nil {
+		return nil, ErrInvalidIndex
+	}
+	e.Local = e.Prefix.Local()
+	e.Multicast = e.Prefix.Multicast()
+	return &e, nil
+}
+
+// Find the entry with the key using binary search.
+// Assumes the mutex is held by the caller.
+func (db *mappedDB) find(key uint64) (*Entry, error) {
+	if db.b == nil {
+		return nil, ErrClosed
+	}
+	i := sort.Search(db.count, func(i int) bool {
+		return db.key(i) >= key
+	})
+	if i == db.count || db.key(i) != key {
+		return nil, ErrNotFound
+	}
+	return db.entry(i)
+}
+
+// Find the most specific entry containing the address.
+// Only the first n bytes of addr are known.
+func (db *mappedDB) lookUp(addr MAC, n int) (*Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, bits := range blockBits {
+		if n*8 < bits {
+			continue
+		}
+		e, err := db.find(indexKey(addr, bits))
+		if err != ErrNotFound {
+			return e, err
+		}
+	}
+	return db.find(indexKey(addr, 24))
+}
+
+// Query the database for an entry based on the mac address
+// The most specific entry will be returned.
+// If none are found ErrNotFound will be returned.
+func (db *mappedDB) Query(mac string) (*Entry, error) {
+	addr, n, err := parseAddr(mac)
+	if err != nil {
+		return nil, err
+	}
+	return db.lookUp(addr, n)
+}
+
+// LookUp a hardware address and return the entry if any are found.
+// If none are found ErrNotFound will be returned.
+func (db *mappedDB) LookUp(hw HardwareAddr) (*Entry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.find(indexKey(MAC{hw[0], hw[1], hw[2]}, 24))
+}
+
+// LookUpMAC a full mac address and return the most specific entry if any are found.
+// If none are found ErrNotFound will be returned.
+func (db *mappedDB) LookUpMAC(m MAC) (*Entry, error) {
+	return db.lookUp(m, len(m))
+}
+
+// Get the generated time
+func (db *mappedDB) Generated() time.Time {
+	return db.dbTime
+}
+
+// RawDB returns the 24 bit OUI entries as a map.
+// The map is built on the first call.
+func (db *mappedDB) RawDB() map[[3]byte]Entry {
+	db.rawOnce.Do(func() {
+		db.raw = make(map[[3]byte]Entry)
+		for _, e := range db.entries() {
+			if e.Block == nil {
+				db.raw[e.Prefix] = e
+			}
+		}
+	})
+	return db.raw
+}
+
+// The content of a mapped database never changes.
+func (db *mappedDB) contentID() interface{} {
+	return db
+}
+
+// Return all entries sorted by prefix.
+func (db *mappedDB) entries() []Entry {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	res := make([]Entry, 0, db.count)
+	for i := 0; i < db.count; i++ {
+		e, err := db.entry(i)
+		if err != nil {
+			continue
+		}
+		res = append(res, *e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return entryLess(&res[i], &res[j])
+	})
+	return res
+}
+
+// Len returns the number of entries.
+func (db *mappedDB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.count
+}
+
+// All returns an iterator over all entries sorted by prefix.
+func (db *mappedDB) All() func(yield func(Entry) bool) {
+	return iterate(db.entries)
+}
+
+// A mapped database is read only, so entries cannot be added.
+func (db *mappedDB) set(HardwareAddr, Entry) {}
+
+// The generation time is read from the index.
+func (db *mappedDB) generatedAt(*time.Time) {}
@@ -0,0 +1,141 @@
+package oui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write the index of db to a file and return the name.
+func writeIndexFile(t *testing.T, db OuiDB) string {
+	var buf bytes.Buffer
+	if err := WriteIndex(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "oui.idx")
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestMappedRoundTrip(t *testing.T) {
+	src := testDB(t)
+	db, err := OpenMapped(writeIndexFile(t, src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if changes := Diff(src, db); len(changes) != 0 {
+		t.Fatalf("index differs from the source: %+v", changes)
+	}
+	if db.Len() != src.Len() || !db.Generated().Equal(src.Generated()) {
+		t.Fatalf("got %d entries generated %v", db.Len(), db.Generated())
+	}
+
+	// The most specific entry is returned.
+	for mac, want := range map[string]string{
+		"70:b3:d5:f2:c1:a5": "Tiny IoT",
+		"70:b3:d5:f3:00:00": "Big Umbrella",
+		"00:60:94:01:02:03": "IBM Corp",
+	} {
+		e, err := db.Query(mac)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Manufacturer != want {
+			t.Errorf("%s: got %q, want %q", mac, e.Manufacturer, want)
+		}
+	}
+	e, err := db.LookUpMAC(MAC{0x70, 0xb3, 0xd5, 0xf2, 0xc1, 0x00})
+	if err != nil || e.Block == nil || e.Block.Bits != 36 {
+		t.Fatalf("unexpected entry %v %v", e, err)
+	}
+	if _, err := db.Query("70:b3:d5"); err != ErrNotFound {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+
+	raw := db.RawDB()
+	if len(raw) != 2 || raw[[3]byte{0x00, 0x60, 0x92}].Manufacturer != "MICRO/SYS, INC." {
+		t.Fatalf("unexpected raw database %v", raw)
+	}
+}
+
+func TestMappedClose(t *testing.T) {
+	db, err := OpenMapped(writeIndexFile(t, testDB(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := db.Query("00:60:92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Returned entries are copies.
+	if e.Manufacturer != "MICRO/SYS, INC." {
+		t.Fatalf("entry changed after close: %v", e)
+	}
+	if _, err := db.Query("00:60:92"); err != ErrClosed {
+		t.Fatalf("want ErrClosed, got %v", err)
+	}
+	if _, err := db.LookUpMAC(MAC{0x70, 0xb3, 0xd5, 0xf2, 0xc1, 0x00}); err != ErrClosed {
+		t.Fatalf("want ErrClosed, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("second close: %v", err)
+	}
+}
+
+func TestMappedInvalid(t *testing.T) {
+	name := writeIndexFile(t, testDB(t))
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a bit in the entry data, so the checksum fails.
+	corrupt := append([]byte(nil), b...)
+	corrupt[len(corrupt)-5] ^= 1
+	for what, idx := range map[string][]byte{
+		"corrupt":   corrupt,
+		"truncated": b[:len(b)-1],
+		"header":    b[:indexHeaderSize-1],
+		"magic":     append([]byte("OUIIDY"), b[6:]...),
+	} {
+		if err := os.WriteFile(name, idx, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if db, err := OpenMapped(name); err != ErrInvalidIndex {
+			if err == nil {
+				db.Close()
+			}
+			t.Errorf("%s: want ErrInvalidIndex, got %v", what, err)
+		}
+	}
+}
+
+func TestMappedCrafted(t *testing.T) {
+	// One record with a manufacturer length far beyond the data.
+	rec := make([]byte, indexRecordSize)
+	binary.LittleEndian.PutUint64(rec, indexKey(MAC{0, 0, 1}, 24))
+	data := uvarint(1 << 62)
+	b := make([]byte, indexHeaderSize)
+	copy(b, indexMagic)
+	binary.LittleEndian.PutUint32(b[8:], indexVersion)
+	binary.LittleEndian.PutUint32(b[12:], 1)
+	crc := crc32.Update(0, snapshotTable, rec)
+	binary.LittleEndian.PutUint32(b[24:], crc32.Update(crc, snapshotTable, data))
+	b = append(append(b, rec...), data...)
+
+	db, err := newMapped(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Query("00:00:01"); err != ErrInvalidIndex {
+		t.Fatalf("want ErrInvalidIndex, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package oui
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// MergeStrategy decides which entry is kept when several sources
+// have an entry for the same prefix.
+type MergeStrategy int
+
+const (
+	// MergePreferIEEE keeps the entry from an IEEE registry over entries
+	// from other sources, like Wireshark 'manuf' files.
+	// If several IEEE sources, or no IEEE sources, have the entry,
+	// the entry of the first of them is kept.
+	MergePreferIEEE MergeStrategy = iota
+	// MergePreferRecent keeps the entry from the source with the latest
+	// generation time. If the times are equal, the entry of the first source is kept.
+	MergePreferRecent
+	// MergeKeepBoth keeps the entry of the first source, and fills in the information
+	// it is missing from the entries of other sources for the same organization,
+	// for instance the short name from a 'manuf' file and the address from an IEEE registry.
+	// Entries naming a different organization are never mixed in, they are
+	// kept as the Alternates of the conflict in the report.
+	MergeKeepBoth
+)
+
+// String returns the name of the strategy.
+func (m MergeStrategy) String() string {
+	switch m {
+	case MergePreferIEEE:
+		return "prefer-ieee"
+	case MergePreferRecent:
+		return "prefer-recent"
+	case MergeKeepBoth:
+		return "keep-both"
+	}
+	return "unknown"
+}
+
+// MergeSource is a database to merge.
+type MergeSource struct {
+	// Name identifies the source in the conflict report.
+	Name string
+	DB   OuiDB
+	// IEEE is true if the source is an IEEE registry.
+	IEEE bool
+}
+
+// Conflict describes a prefix where the sources disagree
+// on the organization the prefix is assigned to.
+type Conflict struct {
+	// Prefix is the prefix, for instance "00:60:92" or "70:b3:d5:f2:c0:00/36".
+	Prefix string
+	// Entries are the competing entries, in the order of the sources.
+	// The Layer field is set to the name of the source.
+	Entries []Entry
+	// Kept is the entry stored in the merged database.
+	Kept Entry
+	// Alternates are the entries naming a different organization than Kept,
+	// in the order of the sources. The Layer field is set to the name of the source.
+	Alternates []Entry
+}
+
+// MergeReport lists the conflicts found when merging.
+type MergeReport struct {
+	// Conflicts are sorted by prefix.
+	Conflicts []Conflict
+}
+
+// A merged entry and where it came from.
+type mergedEntry struct {
+	entry Entry
+	src   int
+	// Entries from all sources, if more than one source has the prefix.
+	all      []Entry
+	conflict bool
+}
+
+// Merge combines the entries of the sources into a new database,
+// using the strategy to decide which entry to keep when several sources
+// have an entry for the same prefix.
+// Entries are considered conflicting if the organization names differ by more
+// than case, punctuation or company suffix. Every conflicting prefix is reported.
+// The generation time is the latest generation time of the sources.
+func Merge(strategy MergeStrategy, sources ...MergeSource) (StaticDB, *MergeReport) {
+	merged := make(map[string]*mergedEntry)
+	var generated time.Time
+	for i, src := range sources {
+		if g := src.DB.Generated(); g.After(generated) {
+			generated = g
+		}
+		for _, e := range src.DB.entries() {
+			key := entryPrefix(&e)
+			m, ok := merged[key]
+			if !ok {
+				merged[key] = &mergedEntry{entry: e, src: i}
+				continue
+			}
+			if m.all == nil {
+				first := m.entry
+				first.Layer = sources[m.src].Name
+				m.all = []Entry{first}
+			}
+			c := e
+			c.Layer = src.Name
+			m.all = append(m.all, c)
+			if normalizeName(m.all[0].Manufacturer) != normalizeName(e.Manufacturer) {
+				m.conflict = true
+			}
+			switch strategy {
+			case MergePreferIEEE:
+				if src.IEEE && !sources[m.src].IEEE {
+					m.entry, m.src = e, i
+				}
+			case MergePreferRecent:
+				if src.DB.Generated().After(sources[m.src].DB.Generated()) {
+					m.entry, m.src = e, i
+				}
+			case MergeKeepBoth:
+				if normalizeName(m.entry.Manufacturer) == normalizeName(e.Manufacturer) {
+					fillEntry(&m.entry, &e)
+				}
+			}
+		}
+	}
+
+	dst := newOuiDB()
+	report := &MergeReport{}
+	for key, m := range merged {
+		m.entry.Layer = ""
+		dst.set(m.entry.Prefix, m.entry)
+		if m.conflict {
+			c := Conflict{Prefix: key, Entries: m.all, Kept: m.entry}
+			kept := normalizeName(m.entry.Manufacturer)
+			for _, e := range m.all {
+				if normalizeName(e.Manufacturer) != kept {
+					c.Alternates = append(c.Alternates, e)
+				}
+			}
+			report.Conflicts = append(report.Conflicts, c)
+		}
+	}
+	sort.Slice(report.Conflicts, func(i, j int) bool {
+		return entryLess(&report.Conflicts[i].Kept, &report.Conflicts[j].Kept)
+	})
+	db := newStatic(dst)
+	if !generated.IsZero() {
+		db.generatedAt(&generated)
+	}
+	return db, report
+}
+
+// Fill in the information dst is missing from src.
+func fillEntry(dst, src *Entry) {
+	if dst.Manufacturer == "" {
+		dst.Manufacturer = src.Manufacturer
+	}
+	if dst.ShortName == "" {
+		dst.ShortName = src.ShortName
+	}
+	if len(dst.Address) == 0 {
+		dst.Address = src.Address
+	}
+	if dst.Country == "" {
+		dst.Country = src.Country
+	}
+	if dst.Registry == "" {
+		dst.Registry = src.Registry
+	}
+}
+
+// MergeFiles reads each of the files and merges them using the strategy.
+// Files in the IEEE text and CSV formats are considered IEEE registries.
+// The sources are named by the file names.
+// See Merge for details.
+func MergeFiles(names []string, strategy MergeStrategy, opts ...Option) (StaticDB, *MergeReport, error) {
+	o := getOptions(opts)
+	sources := make([]MergeSource, 0, len(names))
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		fo := *o
+		fo.hint = compressionFromName(name)
+		fo.source = name
+		dst := newOuiDB()
+		s := newScanner(file, &fo)
+		for s.Scan() {
+			e := s.Entry()
+			dst.set(e.Prefix, e)
+		}
+		file.Close()
+		if err := s.Err(); err != nil {
+			return nil, nil, err
+		}
+		db := newStatic(dst)
+		if t := s.Generated(); !t.IsZero() {
+			db.generatedAt(&t)
+		}
+		f := s.Format()
+		sources = append(sources, MergeSource{Name: name, DB: db, IEEE: f == FormatText || f == FormatCSV})
+	}
+	db, report := Merge(strategy, sources...)
+	return db, report, nil
+}
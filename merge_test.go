@@ -0,0 +1,94 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Return the merge sources used in the tests.
+// The local source assigns 00:60:92 to another organization,
+// and the manuf source has the short name of IBM.
+func mergeSources(t *testing.T) (local, manuf, ieee MergeSource) {
+	l, err := OpenStatic(strings.NewReader("006092 Local Corp\n"), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenStatic(strings.NewReader("00:60:92\tMicroSys\tMicro/Sys Inc\n00:60:94\tIBM\tIBM Corp.\n"), WithFormat(FormatManuf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return MergeSource{Name: "local", DB: l}, MergeSource{Name: "manuf", DB: m}, MergeSource{Name: "ieee", DB: testDB(t), IEEE: true}
+}
+
+func TestMergeKeepBoth(t *testing.T) {
+	local, manuf, ieee := mergeSources(t)
+	db, report := Merge(MergeKeepBoth, local, manuf, ieee)
+
+	// The entry of the first source is kept, without information
+	// from the entries of other organizations.
+	e, err := db.Query("00:60:92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Local Corp" || len(e.Address) != 0 || e.Country != "" || e.ShortName != "" {
+		t.Fatalf("entry mixed with another organization: %v", e)
+	}
+
+	// Information is combined for the same organization.
+	e, err = db.Query("00:60:94")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "IBM Corp." || e.ShortName != "IBM" || len(e.Address) == 0 {
+		t.Fatalf("entry not filled in: %v", e)
+	}
+
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("want 1 conflict, got %+v", report.Conflicts)
+	}
+	c := report.Conflicts[0]
+	if c.Prefix != "00:60:92" || len(c.Entries) != 3 || c.Kept.Manufacturer != "Local Corp" {
+		t.Fatalf("unexpected conflict %+v", c)
+	}
+	if len(c.Alternates) != 2 || c.Alternates[0].Layer != "manuf" || c.Alternates[1].Layer != "ieee" ||
+		c.Alternates[1].Manufacturer != "MICRO/SYS, INC." || len(c.Alternates[1].Address) == 0 {
+		t.Fatalf("unexpected alternates %+v", c.Alternates)
+	}
+}
+
+func TestMergePreferIEEE(t *testing.T) {
+	local, manuf, ieee := mergeSources(t)
+	db, report := Merge(MergePreferIEEE, local, manuf, ieee)
+	e, err := db.Query("00:60:92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "MICRO/SYS, INC." || e.Layer != "" {
+		t.Fatalf("IEEE entry not kept: %v", e)
+	}
+	if db.Len() != testDB(t).Len() {
+		t.Fatalf("got %d entries", db.Len())
+	}
+	c := report.Conflicts[0]
+	if len(c.Alternates) != 1 || c.Alternates[0].Manufacturer != "Local Corp" {
+		t.Fatalf("unexpected alternates %+v", c.Alternates)
+	}
+}
+
+func TestMergePreferRecent(t *testing.T) {
+	local, _, ieee := mergeSources(t)
+	now := time.Now()
+	local.DB.generatedAt(&now)
+	db, _ := Merge(MergePreferRecent, ieee, local)
+	e, err := db.Query("00:60:92")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Local Corp" {
+		t.Fatalf("recent entry not kept: %v", e)
+	}
+	if !db.Generated().Equal(now) {
+		t.Fatalf("generated %v, want %v", db.Generated(), now)
+	}
+}
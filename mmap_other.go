@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package oui
+
+import (
+	"io/ioutil"
+)
+
+// Memory mapping isn't supported, so the file is read into memory.
+func mmapFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+// The file was read into memory, so there is nothing to unmap.
+func munmapFile(b []byte) error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package oui
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Reads a nmap 'nmap-mac-prefixes' file.
+// Each line contains a prefix as hex digits followed by the name, for instance:
+//
+//	00000C Cisco Systems
+//	70B3D5F2C Tiny IoT
+//
+// The number of digits determine the length of the prefix.
+// The files contain no generation time, so the generation time is always nil.
+type nmapReader struct {
+	scanner *lineScanner
+	p       *parseState
+}
+
+// Create a reader of the nmap format.
+func newNmapReader(in io.Reader, p *parseState) *nmapReader {
+	return &nmapReader{scanner: newLineScanner(in), p: p}
+}
+
+// Return the generation time, which is always nil.
+func (r *nmapReader) generated() *time.Time {
+	return nil
+}
+
+// Return the next entry.
+func (r *nmapReader) next() (*Entry, error) {
+	scanner, p := r.scanner, r.p
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		// The prefix is separated from the name by a space or a tab.
+		i := strings.IndexAny(text, " \t")
+		if i < 0 {
+			if err := p.problem(scanner.line, text, "missing name"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e, err := assignmentEntry(text[:i])
+		if err != nil {
+			if err := p.problem(scanner.line, text, err.Error()); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		e.Manufacturer = strings.TrimSpace(text[i+1:])
+		return e, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Write entries in the nmap 'nmap-mac-prefixes' format.
+func writeNmap(w *bufio.Writer, entries []Entry) error {
+	for i := range entries {
+		e := &entries[i]
+		if _, err := w.WriteString(prefixHex(e) + " " + e.Manufacturer + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
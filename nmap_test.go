@@ -0,0 +1,52 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNmapSeparators(t *testing.T) {
+	const in = "# comment\n000000\tXerox Corp\n00000C Cisco Systems\n70B3D5F2C\tTiny IoT Devices\n"
+	s := NewScanner(strings.NewReader(in))
+	var got []string
+	for s.Scan() {
+		e := s.Entry()
+		got = append(got, entryPrefix(&e)+"="+e.Manufacturer)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if f := s.Format(); f != FormatNmap {
+		t.Fatalf("detected format %v", f)
+	}
+	want := []string{"00:00:00=Xerox Corp", "00:00:0c=Cisco Systems", "70:b3:d5:f2:c0:00/36=Tiny IoT Devices"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNmapMissingName(t *testing.T) {
+	_, err := Open(strings.NewReader("000000 Xerox\n00000C\n"), WithFormat(FormatNmap), WithStrict())
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("want *ParseError, got %v", err)
+	}
+	if perr.Line != 2 {
+		t.Fatalf("want line 2, got %d", perr.Line)
+	}
+}
+
+func TestNmapPrefixLength(t *testing.T) {
+	const in = "000000 Xerox\n0011223344 Forty Bit\n"
+	var report ParseReport
+	db, err := Open(strings.NewReader(in), WithFormat(FormatNmap), WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Len() != 1 || len(report.Warnings) != 1 || report.Warnings[0].Line != 2 {
+		t.Fatalf("got %d entries and warnings %v", db.Len(), report.Warnings)
+	}
+	if _, err := Open(strings.NewReader(in), WithFormat(FormatNmap), WithStrict()); err == nil {
+		t.Fatal("strict parsing accepted a 40 bit prefix")
+	}
+}
@@ -24,34 +24,164 @@ package oui
  */
 
 import (
-	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
+	"math/bits"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// Internal representation of the database content
-type ouiDB map[[3]byte]Entry
+// Internal representation of the database content.
+// 24 bit OUI entries are stored in oui, while
+// MA-M and MA-S entries are stored in blocks.
+type ouiDB struct {
+	oui    map[[3]byte]Entry
+	blocks map[Block]Entry
+	// Index by manufacturer, built on first search.
+	idx *nameIndex
+	// Index of words for fuzzy searches, built on first search.
+	fuzzy *fuzzyIndex
+}
+
+// Create a new empty database content.
+func newOuiDB() ouiDB {
+	return ouiDB{oui: make(map[[3]byte]Entry), blocks: make(map[Block]Entry), idx: &nameIndex{}, fuzzy: &fuzzyIndex{}}
+}
 
-// Set an element to contain this value
+// Set an element to contain this value.
+// If the entry has a Block, it is stored as a block entry.
 func (db ouiDB) set(hw HardwareAddr, e Entry) {
-	db[[3]byte(hw)] = e
+	if e.Block != nil {
+		db.blocks[NewBlock(e.Block.Addr, e.Block.Bits)] = e
+		return
+	}
+	db.oui[[3]byte(hw)] = e
 }
 
 // Delete an element. If the element does not exist,
 // the function will just return.
 func (db ouiDB) del(hw HardwareAddr) {
-	delete(db, [3]byte(hw))
+	delete(db.oui, [3]byte(hw))
+}
+
+// Delete a block element. If the element does not exist,
+// the function will just return.
+func (db ouiDB) delBlock(b Block) {
+	delete(db.blocks, NewBlock(b.Addr, b.Bits))
+}
+
+// Return the entry stored for the OUI or block, if any.
+func (db ouiDB) get(hw HardwareAddr, b *Block) (Entry, bool) {
+	if b != nil {
+		e, ok := db.blocks[NewBlock(b.Addr, b.Bits)]
+		return e, ok
+	}
+	e, ok := db.oui[[3]byte(hw)]
+	return e, ok
+}
+
+// Return a copy of the database where the OUI entries can be modified.
+// The block entries are shared with db, and the copy gets new indexes.
+func (db ouiDB) copyOui() ouiDB {
+	m := make(map[[3]byte]Entry, len(db.oui)+1)
+	for k, v := range db.oui {
+		m[k] = v
+	}
+	return ouiDB{oui: m, blocks: db.blocks, idx: &nameIndex{}, fuzzy: &fuzzyIndex{}}
+}
+
+// Return a copy of the database where the block entries can be modified.
+// The OUI entries are shared with db, and the copy gets new indexes.
+func (db ouiDB) copyBlocks() ouiDB {
+	m := make(map[Block]Entry, len(db.blocks)+1)
+	for k, v := range db.blocks {
+		m[k] = v
+	}
+	return ouiDB{oui: db.oui, blocks: m, idx: &nameIndex{}, fuzzy: &fuzzyIndex{}}
+}
+
+// Return the number of entries.
+func (db ouiDB) len() int {
+	return len(db.oui) + len(db.blocks)
+}
+
+// The block prefix lengths in the order they are searched.
+var blockBits = [...]int{36, 28}
+
+// Find the most specific entry containing the address.
+// Only the first n bytes of addr are known, so longer
+// blocks will only be searched if enough bytes are given.
+func (db ouiDB) lookUp(addr MAC, n int) (*Entry, error) {
+	if len(db.blocks) > 0 {
+		for _, bits := range blockBits {
+			if n*8 < bits {
+				continue
+			}
+			if e, ok := db.blocks[NewBlock(addr, bits)]; ok {
+				return &e, nil
+			}
+		}
+	}
+	e, ok := db.oui[[3]byte{addr[0], addr[1], addr[2]}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &e, nil
+}
+
+// Return all entries sorted by prefix.
+// An OUI entry is sorted before the blocks it contains.
+func (db ouiDB) sorted() []Entry {
+	res := make([]Entry, 0, len(db.oui)+len(db.blocks))
+	for _, e := range db.oui {
+		res = append(res, e)
+	}
+	for _, e := range db.blocks {
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return entryLess(&res[i], &res[j])
+	})
+	return res
+}
+
+// Return an iterator over the entries returned by fn
+// when the iteration starts.
+func iterate(fn func() []Entry) func(yield func(Entry) bool) {
+	return func(yield func(Entry) bool) {
+		for _, e := range fn() {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// entryLess returns true if a should be sorted before b.
+func entryLess(a, b *Entry) bool {
+	if a.Prefix != b.Prefix {
+		return bytes.Compare(a.Prefix[:], b.Prefix[:]) < 0
+	}
+	if a.Block == nil || b.Block == nil {
+		return a.Block == nil && b.Block != nil
+	}
+	if a.Block.Addr != b.Block.Addr {
+		return bytes.Compare(a.Block.Addr[:], b.Block.Addr[:]) < 0
+	}
+	return a.Block.Bits < b.Block.Bits
 }
 
 // This interface can be used to access the raw
 // database. This interface is available on Static databases.
+// Only 24 bit OUI entries are included; MA-M and MA-S entries
+// can only be found using the OuiDB interface.
 type RawGetter interface {
 	RawDB() map[[3]byte]Entry
 }
@@ -63,20 +193,54 @@ var ErrNotFound = errors.New("not found")
 // OuiDB represents a database that allow you to look up Hardware Addresses
 type OuiDB interface {
 	// Query the database for an entry based on the mac address
+	// The most specific entry will be returned, so MA-S entries are
+	// preferred over MA-M entries, which are preferred over OUI entries.
 	// If none are found ErrNotFound will be returned.
 	Query(string) (*Entry, error)
 
 	// Look up a hardware address and return the entry if any are found.
+	// Since a HardwareAddr only contains 24 bits, only OUI entries are returned.
 	// If none are found ErrNotFound will be returned.
 	LookUp(HardwareAddr) (*Entry, error)
 
+	// Look up a full mac address and return the most specific entry if any are found.
+	// If none are found ErrNotFound will be returned.
+	LookUpMAC(MAC) (*Entry, error)
+
 	// Returns the generation time of the database
 	// May return the zero time if unparsable
 	Generated() time.Time
 
+	// Len returns the number of entries, including MA-M and MA-S entries.
+	Len() int
+
+	// All returns an iterator over all entries sorted by prefix.
+	// An OUI entry is returned before the blocks it contains.
+	// The entries are read from the content when the iteration starts,
+	// so changes made while iterating are not seen.
+	// It can be used with range:
+	//	for e := range db.All() {
+	//		fmt.Println(e.String())
+	//	}
+	All() func(yield func(Entry) bool)
+
+	// SearchManufacturer returns all entries where the manufacturer matches name,
+	// sorted by prefix. The match mode decides how names are compared.
+	SearchManufacturer(name string, m MatchMode) []Entry
+
+	// Search returns the entries where the manufacturer or address best match
+	// the query, ranked by score, best first. Small typos are tolerated,
+	// and the last word of the query may be incomplete, so it can be used
+	// for autocompletion. At most limit results are returned, unless limit is 0 or less.
+	Search(query string, limit int) []SearchResult
+
 	// Internal functions
 	set(HardwareAddr, Entry)
 	generatedAt(*time.Time)
+	entries() []Entry
+	// Return a value that is only equal to earlier values
+	// if the content hasn't changed since.
+	contentID() interface{}
 }
 
 // StaticDB is a database containing OUI entries that doesn't
@@ -96,23 +260,18 @@ type DynamicDB interface {
 	Updater
 }
 
-// Create a new dynamic database with optional content.
-// You can pass nil as parameter, which will initialize the database.
+// Create a new dynamic database with the content.
 // A database returned from this can be expected to implement the Updater interface.
-func newDynamic(c map[[3]byte]Entry) DynamicDB {
-	if c == nil {
-		c = make(map[[3]byte]Entry)
-	}
-	return &updateableDB{ouiDB: c}
+// The history and source are given by the options.
+func newDynamic(c ouiDB, o *options) DynamicDB {
+	db := &updateableDB{keep: o.history, versions: 1}
+	db.state.Store(&dbState{ouiDB: c, version: 1, source: o.source, loaded: time.Now()})
+	return db
 }
 
-// Create a new static database with optional content.
-// You can pass nil as parameter, which will initialize the database.
+// Create a new static database with the content.
 // A database returned from this can be expected to implement the RawGetter interface.
-func newStatic(c map[[3]byte]Entry) StaticDB {
-	if c == nil {
-		c = make(map[[3]byte]Entry)
-	}
+func newStatic(c ouiDB) StaticDB {
 	return &staticDB{ouiDB: c}
 }
 
@@ -128,29 +287,56 @@ var _ RawGetter = staticDB{}
 
 // Satisfy the RawGetter interface
 func (db staticDB) RawDB() map[[3]byte]Entry {
-	return db.ouiDB
+	return db.ouiDB.oui
 }
 
 // Query the database for an entry based on the mac address
+// The most specific entry will be returned.
 // If none are found ErrNotFound will be returned.
 func (db staticDB) Query(mac string) (*Entry, error) {
-	hw, err := ParseMac(mac)
+	addr, n, err := parseAddr(mac)
 	if err != nil {
 		return nil, err
 	}
-	return db.LookUp(*hw)
+	return db.ouiDB.lookUp(addr, n)
 }
 
 // LookUp a hardware address and return the entry if any are found.
 // If none are found ErrNotFound will be returned.
 func (o staticDB) LookUp(hw HardwareAddr) (*Entry, error) {
-	e, ok := o.ouiDB[hw]
+	e, ok := o.ouiDB.oui[hw]
 	if !ok {
 		return nil, ErrNotFound
 	}
 	return &e, nil
 }
 
+// LookUpMAC a full mac address and return the most specific entry if any are found.
+// If none are found ErrNotFound will be returned.
+func (o staticDB) LookUpMAC(m MAC) (*Entry, error) {
+	return o.ouiDB.lookUp(m, len(m))
+}
+
+// Return all entries sorted by prefix.
+func (o staticDB) entries() []Entry {
+	return o.ouiDB.sorted()
+}
+
+// The indexes are created with the content, so they identify it.
+func (o staticDB) contentID() interface{} {
+	return o.ouiDB.idx
+}
+
+// Len returns the number of entries.
+func (o staticDB) Len() int {
+	return o.ouiDB.len()
+}
+
+// All returns an iterator over all entries sorted by prefix.
+func (o staticDB) All() func(yield func(Entry) bool) {
+	return iterate(o.entries)
+}
+
 // Get the generated time
 func (o staticDB) Generated() time.Time {
 	return time.Time(o.dbTime)
@@ -164,121 +350,350 @@ func (d *staticDB) generatedAt(t *time.Time) {
 	d.dbTime = *t
 }
 
-// An updateable database.
-// There is a mutex protecting read/write access to the database.
-type updateableDB struct {
+// The content of an updateable database at one point in time.
+// Once a state has been published it is never modified,
+// so it can be read without locking.
+type dbState struct {
 	ouiDB
 	dbTime time.Time
-	mu     sync.RWMutex
+	// Validators of the last HTTP download.
+	http *httpValidators
+
+	// The version, where the content came from and when it became current.
+	version int
+	source  string
+	loaded  time.Time
+}
+
+// Set an entry, and return the event if requested.
+// The map modified must have been copied.
+func (s *dbState) update(hw HardwareAddr, e Entry, event bool) []Event {
+	var prev *Entry
+	if event {
+		if p, ok := s.get(hw, e.Block); ok {
+			prev = &p
+		}
+	}
+	s.ouiDB.set(hw, e)
+	if !event {
+		return nil
+	}
+	return []Event{{Kind: EventUpdate, Entry: &e, Previous: prev}}
+}
+
+// Delete an entry, and return the event if requested and the entry existed.
+// The map modified must have been copied.
+func (s *dbState) delete(hw HardwareAddr, event bool) []Event {
+	e, ok := s.oui[hw]
+	s.ouiDB.del(hw)
+	if !event || !ok {
+		return nil
+	}
+	return []Event{{Kind: EventDelete, Entry: &e}}
+}
+
+// Delete a block entry, and return the event if requested and the entry existed.
+// The map modified must have been copied.
+func (s *dbState) deleteBlock(b Block, event bool) []Event {
+	e, ok := s.get(b.OUI(), &b)
+	s.ouiDB.delBlock(b)
+	if !event || !ok {
+		return nil
+	}
+	return []Event{{Kind: EventDelete, Entry: &e}}
+}
+
+// An updateable database.
+// The current content is published as an immutable *dbState,
+// so queries never lock. Writers are serialized by a mutex
+// and publish a modified copy of the state.
+type updateableDB struct {
+	state atomic.Value
+	mu    sync.Mutex
+	// Subscriptions to changes, protected by mu.
+	subs []*Subscription
+
+	// Previous versions, oldest first, protected by mu.
+	history []*dbState
+	// The number of previous versions to keep.
+	keep int
+	// The last version number used, protected by mu.
+	versions int
 }
 
 // Check we implement the interfaces we promise
 var _ Updater = &updateableDB{}
 var _ OuiDB = &updateableDB{}
 
+// Return the current state.
+func (o *updateableDB) load() *dbState {
+	return o.state.Load().(*dbState)
+}
+
+// Publish a modified copy of the current state.
+// The function is called with a copy of the state, and must copy
+// any map it modifies, since the maps are shared with the current state.
+// The events returned are sent to the subscribers once the state is published.
+// Events are only needed if there are subscribers.
+func (o *updateableDB) modify(fn func(s *dbState) []Event) {
+	o.mu.Lock()
+	s := *o.load()
+	o.publish(&s, fn(&s))
+	o.mu.Unlock()
+}
+
+// Publish the state and send the events to the subscribers.
+// Must be called with the write lock held.
+func (o *updateableDB) publish(s *dbState, events []Event) {
+	o.state.Store(s)
+	for _, sub := range o.subs {
+		sub.send(events)
+	}
+}
+
 // Query the database for an entry based on the mac address
+// The most specific entry will be returned.
 // If none are found ErrNotFound will be returned.
 func (db *updateableDB) Query(mac string) (*Entry, error) {
-	hw, err := ParseMac(mac)
+	addr, n, err := parseAddr(mac)
 	if err != nil {
 		return nil, err
 	}
-	return db.LookUp(*hw)
+	return db.load().lookUp(addr, n)
 }
 
 // Look up a hardware address and return the entry if any are found.
 // If none are found ErrNotFound will be returned.
 func (o *updateableDB) LookUp(hw HardwareAddr) (*Entry, error) {
-	o.mu.RLock()
-	e, ok := o.ouiDB[hw]
-	o.mu.RUnlock()
+	e, ok := o.load().oui[hw]
 	if !ok {
 		return nil, ErrNotFound
 	}
 	return &e, nil
 }
 
+// Look up a full mac address and return the most specific entry if any are found.
+// If none are found ErrNotFound will be returned.
+func (o *updateableDB) LookUpMAC(m MAC) (*Entry, error) {
+	return o.load().lookUp(m, len(m))
+}
+
+// Len returns the number of entries.
+func (o *updateableDB) Len() int {
+	return o.load().ouiDB.len()
+}
+
+// Return all entries sorted by prefix.
+func (o *updateableDB) entries() []Entry {
+	return o.load().sorted()
+}
+
+// Every change publishes a new state, so the state identifies the content.
+func (o *updateableDB) contentID() interface{} {
+	return o.load()
+}
+
+// All returns an iterator over all entries sorted by prefix.
+// The iteration uses the content when it starts, so updates
+// made while iterating are not seen.
+func (o *updateableDB) All() func(yield func(Entry) bool) {
+	return iterate(o.entries)
+}
+
 // Get the generated time
 func (o *updateableDB) Generated() time.Time {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
-	return o.dbTime
+	return o.load().dbTime
 }
 
 // Update "generated at" time
-// Assumes updateableDB mutex is locked by caller.
 func (o *updateableDB) generatedAt(t *time.Time) {
 	if t == nil {
 		return
 	}
-	o.dbTime = *t
+	o.modify(func(s *dbState) []Event {
+		s.dbTime = *t
+		return nil
+	})
+}
+
+// Set an element, the same as UpdateEntry.
+func (o *updateableDB) set(hw HardwareAddr, e Entry) {
+	o.UpdateEntry(hw, e)
 }
 
 // Update the database and replace content with the supplied content.
-func (o *updateableDB) updateDb(db ouiDB, t *time.Time) {
-	o.mu.Lock()
-	o.ouiDB = db
-	o.generatedAt(t)
-	o.mu.Unlock()
+// The validators of the last HTTP download are cleared,
+// since they no longer describe the content.
+// The current content is added to the history, if history is kept.
+// The content must not be modified after this.
+func (o *updateableDB) updateDb(db ouiDB, t *time.Time, source string) {
+	o.modify(func(s *dbState) []Event {
+		old := *s
+		o.pushHistory(o.load())
+		o.versions++
+		*s = dbState{ouiDB: db, version: o.versions, source: source, loaded: time.Now()}
+		if t != nil {
+			s.dbTime = *t
+		}
+		if len(o.subs) == 0 {
+			return nil
+		}
+		return []Event{reloadEvent(&old, s)}
+	})
+}
+
+// Return the validators of the last HTTP download.
+func (o *updateableDB) validators() *httpValidators {
+	return o.load().http
+}
+
+// Set the validators of the last HTTP download.
+func (o *updateableDB) setValidators(v *httpValidators) {
+	o.modify(func(s *dbState) []Event {
+		s.http = v
+		return nil
+	})
 }
 
 // UpdateEntry will update/add a single entry to the database.
+// If the entry has a Block, it is stored as a block entry.
+// The entries of the same kind are copied on every call,
+// so to change many entries use a Batch.
 func (o *updateableDB) UpdateEntry(hw HardwareAddr, e Entry) {
-	o.mu.Lock()
-	o.ouiDB.set(hw, e)
-	o.mu.Unlock()
+	o.modify(func(s *dbState) []Event {
+		if e.Block != nil {
+			s.ouiDB = s.ouiDB.copyBlocks()
+		} else {
+			s.ouiDB = s.ouiDB.copyOui()
+		}
+		return s.update(hw, e, len(o.subs) > 0)
+	})
 }
 
 // DeleteEntry will remove an entry from the database.
 // If the element does not exist, the function will just return.
 func (o *updateableDB) DeleteEntry(hw HardwareAddr) {
-	o.mu.Lock()
-	o.ouiDB.del(hw)
-	o.mu.Unlock()
+	if _, ok := o.load().oui[hw]; !ok {
+		return
+	}
+	o.modify(func(s *dbState) []Event {
+		s.ouiDB = s.ouiDB.copyOui()
+		return s.delete(hw, len(o.subs) > 0)
+	})
+}
+
+// DeleteBlock will remove a MA-M or MA-S entry from the database.
+// If the element does not exist, the function will just return.
+func (o *updateableDB) DeleteBlock(b Block) {
+	if _, ok := o.load().blocks[NewBlock(b.Addr, b.Bits)]; !ok {
+		return
+	}
+	o.modify(func(s *dbState) []Event {
+		s.ouiDB = s.ouiDB.copyBlocks()
+		return s.deleteBlock(b, len(o.subs) > 0)
+	})
 }
 
 // The Updater interface will be satisfied if the database was opened as a dynamic database.
 // This can be used to safely update the database, even while queries are running.
 type Updater interface {
 	// UpdateEntry will update/add a single entry to the database.
+	// If the entry has a Block, it is stored as a block entry.
 	UpdateEntry(HardwareAddr, Entry)
 
 	// DeleteEntry will remove an entry from the database. If the element does not exist, nothing should happen
 	DeleteEntry(HardwareAddr)
 
-	updateDb(ouiDB, *time.Time)
+	// DeleteBlock will remove a MA-M or MA-S entry from the database. If the element does not exist, nothing should happen
+	DeleteBlock(Block)
+
+	// Begin starts a batch of changes, which are applied atomically when committed.
+	Begin() *Batch
+
+	// Subscribe returns a subscription to changes of the database.
+	// The buffer is the number of events that can wait to be received,
+	// the channel has one more slot reserved for EventDropped.
+	Subscribe(buffer int) *Subscription
+
+	// History returns the versions kept in the history followed
+	// by the current version, oldest first.
+	// History is only kept if WithHistory was given when opening the database.
+	History() []Version
+
+	// Rollback atomically replaces the content with the version with the given ID.
+	// The content becomes a new version with a new ID.
+	// If the version isn't in the history, ErrNoVersion is returned.
+	Rollback(id int) error
+
+	updateDb(ouiDB, *time.Time, string)
+	validators() *httpValidators
+	setValidators(*httpValidators)
+}
+
+// The format of the generation time in the text format.
+const generatedFormat = "Mon, 2 Jan 2006 15:04:05 -0700"
+
+// Matches the "(base 16)" line of entries. MA-M and MA-S entries
+// contain the range of addresses assigned instead of the OUI.
+var base16 = regexp.MustCompile(`^\s*([0-9a-fA-F]{6})(?:-([0-9a-fA-F]{6}))?\s+\(base 16\)`)
+
+// Matches the column headers of the text format, for instance
+// "OUI/MA-L<tabs>Organization", "company_id<tabs>Organization" and "<tabs>Address".
+var textHeader = regexp.MustCompile(`^\s*\S+\s+Organization\s*$|^\s*Address\s*$`)
+
+// Matches the prefix of entries in the text format.
+var textPrefix = regexp.MustCompile(`((?:(?:[0-9a-zA-Z]{2})[-:]){2,5}(?:[0-9a-zA-Z]{2}))(?:/(\w{1,2}))?`)
+
+// Reads an oui file.
+// This reads the text format used by oui.txt, mam.txt, oui36.txt and iab.txt.
+type textReader struct {
+	scanner *lineScanner
+	p       *parseState
+	gen     *time.Time
 }
 
-// Read an oui file.
-func scanOUI(in io.Reader, db ouiDB) (*time.Time, error) {
-	buffered := bufio.NewReader(in)
-	scanner := bufio.NewScanner(buffered)
-	re := regexp.MustCompile(`((?:(?:[0-9a-zA-Z]{2})[-:]){2,5}(?:[0-9a-zA-Z]{2}))(?:/(\w{1,2}))?`)
-	var generated *time.Time
+// Create a reader of the text format.
+func newTextReader(in io.Reader, p *parseState) *textReader {
+	return &textReader{scanner: newLineScanner(in), p: p}
+}
+
+// Return the generation time, if it has been read.
+func (r *textReader) generated() *time.Time {
+	return r.gen
+}
 
+// Return the next entry.
+func (r *textReader) next() (*Entry, error) {
+	scanner, p := r.scanner, r.p
 	for scanner.Scan() {
-		if len(scanner.Text()) == 0 || scanner.Text()[0] == '#' {
+		if len(strings.TrimSpace(scanner.Text())) == 0 || scanner.Text()[0] == '#' {
 			continue
 		}
 
 		arr := strings.Split(scanner.Text(), "\t")
-		if len(arr) == 0 {
-			continue
-		}
 		// Attempt to find generation time
 		t0 := strings.TrimSpace(arr[0])
 		if strings.HasPrefix(t0, "Generated: ") {
 			t0 = t0[11:]
-			t, err := time.Parse("Mon, 2 Jan 2006 15:04:05 -0700", t0)
-			// We ignore the error
-			if err == nil {
-				generated = &t
+			t, err := time.Parse(generatedFormat, t0)
+			if err != nil {
+				if err := p.problem(scanner.line, scanner.Text(), "invalid generation time"); err != nil {
+					return nil, err
+				}
+				continue
 			}
+			r.gen = &t
 			continue
 
 		}
-		matches := re.FindAllStringSubmatch(arr[0], -1)
+		matches := textPrefix.FindAllStringSubmatch(arr[0], -1)
 		if len(matches) == 0 {
+			if textHeader.MatchString(scanner.Text()) {
+				continue
+			}
+			if err := p.problem(scanner.line, scanner.Text(), "unexpected line"); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -286,20 +701,51 @@ func scanOUI(in io.Reader, db ouiDB) (*time.Time, error) {
 
 		bt, err := ParseMac(s)
 		if err != nil {
-			return generated, err
+			if err := p.problem(scanner.line, scanner.Text(), err.Error()); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
-		e := Entry{Prefix: *bt, Manufacturer: arr[len(arr)-1]}
+		e := Entry{Prefix: *bt, Manufacturer: strings.TrimSpace(arr[len(arr)-1])}
+		if len(arr) < 2 || e.Manufacturer == "" {
+			if err := p.problem(scanner.line, scanner.Text(), "missing organization name"); err != nil {
+				return nil, err
+			}
+		}
+		// An entry with an invalid range is skipped, since it would
+		// otherwise be stored as an entry for the entire OUI.
+		invalid := false
 		for scanner.Scan() {
 			text := scanner.Text()
-			if len(text) < 2 {
+			if len(strings.TrimSpace(text)) == 0 {
 				break
 			}
-			if text[0] != '\t' {
+			if m := base16.FindStringSubmatch(text); m != nil {
+				// MA-M and MA-S entries have the assigned range here.
+				if m[2] != "" {
+					b, ok := rangeBlock(*bt, m[1], m[2])
+					if !ok {
+						if err := p.problem(scanner.line, text, "invalid address range"); err != nil {
+							return nil, err
+						}
+						invalid = true
+					}
+					e.Block = b
+				}
+				continue
+			}
+			if strings.TrimLeft(text, " ")[0] != '\t' {
+				if err := p.problem(scanner.line, text, "unexpected line in entry"); err != nil {
+					return nil, err
+				}
 				continue
 			}
 			e.Address = append(e.Address, strings.Trim(text, "\t \r\n"))
 		}
+		if invalid {
+			continue
+		}
 		if len(e.Address) > 0 {
 			e.Country = e.Address[len(e.Address)-1]
 		}
@@ -311,9 +757,37 @@ func scanOUI(in io.Reader, db ouiDB) (*time.Time, error) {
 		if i&multicast != 0 {
 			e.Multicast = true
 		}
-		db[*bt] = e
+		return &e, nil
 	}
-	return generated, nil
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// rangeBlock returns the block of the OUI that covers the
+// hex range from lo to hi, as given in MA-M and MA-S files.
+// If the range covers the entire OUI nil is returned.
+// If the range isn't a valid block false is returned.
+func rangeBlock(hw HardwareAddr, lo, hi string) (*Block, bool) {
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil || h < l {
+		return nil, false
+	}
+	size := h - l + 1
+	if size&(size-1) != 0 || l&(size-1) != 0 {
+		return nil, false
+	}
+	if size == 1<<24 {
+		return nil, true
+	}
+	addr := MAC{hw[0], hw[1], hw[2], byte(l >> 16), byte(l >> 8), byte(l)}
+	b := NewBlock(addr, 48-bits.TrailingZeros64(size))
+	return &b, true
 }
 
 const local = 0x020000
@@ -322,10 +796,10 @@ const multicast = 0x010000
 // OpenStatic will read the content of the given reader and return a database with the content.
 // You will not be able to update this database, but you can request the raw database
 // with the RawDB() function.
-func OpenStatic(in io.Reader) (StaticDB, error) {
-	dst := make(map[[3]byte]Entry)
+func OpenStatic(in io.Reader, opts ...Option) (StaticDB, error) {
+	dst := newOuiDB()
 	db := newStatic(dst)
-	t, err := scanOUI(in, ouiDB(dst))
+	t, err := scan(in, dst, getOptions(opts))
 	db.generatedAt(t)
 	return db, err
 }
@@ -333,150 +807,179 @@ func OpenStatic(in io.Reader) (StaticDB, error) {
 // OpenStaticFile will read the content of a oui.txt file and return a database with the content.
 // You will not be able to update this database, but you can request the raw database
 // with the RawDB() function.
-func OpenStaticFile(name string) (StaticDB, error) {
-	dst := make(map[[3]byte]Entry)
-	file, err := os.Open(name)
+func OpenStaticFile(name string, opts ...Option) (StaticDB, error) {
+	return OpenStaticFiles([]string{name}, opts...)
+}
+
+// OpenStaticFiles will read the content of several files, for instance oui.txt,
+// mam.txt and oui36.txt, and return a database with the combined content.
+// You will not be able to update this database, but you can request the raw database
+// with the RawDB() function.
+func OpenStaticFiles(names []string, opts ...Option) (StaticDB, error) {
+	dst := newOuiDB()
+	t, err := scanFiles(names, dst, getOptions(opts))
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 	db := newStatic(dst)
-	t, err := scanOUI(file, ouiDB(dst))
 	db.generatedAt(t)
-	return db, err
+	return db, nil
 }
 
 // OpenStaticHttp will request the content of the URL given, parse it as a oui.txt file
 // and return a database with the content.
 // You will not be able to update this database, but you can request the raw database
 // with the RawDB() function.
-func OpenStaticHttp(url string) (StaticDB, error) {
-	dst := make(ouiDB)
-	resp, err := http.Get(url)
+func OpenStaticHttp(url string, opts ...Option) (StaticDB, error) {
+	dst := newOuiDB()
+	t, _, err := scanHttp(url, dst, getOptions(opts), nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
 	db := newStatic(dst)
-	t, err := scanOUI(resp.Body, dst)
 	db.generatedAt(t)
-	return db, err
+	return db, nil
 }
 
 // Open will read the content of the given reader and return a database with the content.
 // You can update the returned database using the Update/UpdateFile/UpdateHttp functions.
-func Open(in io.Reader) (DynamicDB, error) {
-	dst := make(map[[3]byte]Entry)
-	db := newDynamic(dst)
-	t, err := scanOUI(in, ouiDB(dst))
+func Open(in io.Reader, opts ...Option) (DynamicDB, error) {
+	o := getOptions(opts)
+	dst := newOuiDB()
+	t, err := scan(in, dst, o)
+	db := newDynamic(dst, o)
 	db.generatedAt(t)
 	return db, err
 }
 
 // OpenFile will read the content of a oui.txt file and return a database with the content.
 // You can update the returned database using the Update/UpdateFile/UpdateHttp functions.
-func OpenFile(name string) (DynamicDB, error) {
-	dst := make(map[[3]byte]Entry)
-	file, err := os.Open(name)
+func OpenFile(name string, opts ...Option) (DynamicDB, error) {
+	return OpenFiles([]string{name}, opts...)
+}
+
+// OpenFiles will read the content of several files, for instance oui.txt,
+// mam.txt and oui36.txt, and return a database with the combined content.
+// You can update the returned database using the Update/UpdateFile/UpdateHttp functions.
+func OpenFiles(names []string, opts ...Option) (DynamicDB, error) {
+	o := getOptions(opts)
+	o.source = strings.Join(names, ",")
+	dst := newOuiDB()
+	t, err := scanFiles(names, dst, o)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-	db := newDynamic(dst)
-	t, err := scanOUI(file, ouiDB(dst))
+	db := newDynamic(dst, o)
 	db.generatedAt(t)
-	return db, err
+	return db, nil
 }
 
 // OpenHttp will request the content of the URL given, parse it as a oui.txt file
 // and return a database with the content.
 // You can update the returned database using the Update/UpdateFile/UpdateHttp functions.
-func OpenHttp(url string) (DynamicDB, error) {
-	dst := make(ouiDB)
-	resp, err := http.Get(url)
+// The ETag and Last-Modified headers of the response are kept, so UpdateHttp
+// can skip the download if the content hasn't changed.
+func OpenHttp(url string, opts ...Option) (DynamicDB, error) {
+	o := getOptions(opts)
+	o.source = url
+	dst := newOuiDB()
+	t, v, err := scanHttp(url, dst, o, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	db := newDynamic(dst)
-	t, err := scanOUI(resp.Body, dst)
+	db := newDynamic(dst, o)
 	db.generatedAt(t)
-	return db, err
+	db.setValidators(v)
+	return db, nil
 }
 
 // Update will read and replace the content of the database.
 // The database will remain usable while the update/parsing
 // is taking place.
-// If an error occurs during read or parsing, the database will not be replaced
+// If an error occurs during read or parsing, or the update is rejected by
+// the UpdatePolicy, the database will not be replaced
 // and the previous version will continue to be served.
-func Update(db DynamicDB, r io.Reader) error {
-	dst := make(ouiDB)
-	t, err := scanOUI(r, dst)
+func Update(db DynamicDB, r io.Reader, opts ...Option) error {
+	o := getOptions(opts)
+	dst := newOuiDB()
+	t, err := scan(r, dst, o)
 	if err != nil {
 		return err
 	}
-	db.updateDb(dst, t)
-	return nil
+	return replace(db, dst, t, o)
 }
 
 // UpdateFile will read a file and replace the content of the database.
 // The database will remain usable while the update/parsing
 // is taking place.
-// If an error occurs during read or parsing, the database will not be replaced
+// If an error occurs during read or parsing, or the update is rejected by
+// the UpdatePolicy, the database will not be replaced
 // and the previous version will continue to be served.
-func UpdateFile(db DynamicDB, name string) error {
-	file, err := os.Open(name)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+func UpdateFile(db DynamicDB, name string, opts ...Option) error {
+	return UpdateFiles(db, []string{name}, opts...)
+}
 
-	dst := make(ouiDB)
-	t, err := scanOUI(file, dst)
+// UpdateFiles will read several files and replace the content of the database
+// with the combined content.
+// The database will remain usable while the update/parsing
+// is taking place.
+// If an error occurs during read or parsing, or the update is rejected by
+// the UpdatePolicy, the database will not be replaced
+// and the previous version will continue to be served.
+func UpdateFiles(db DynamicDB, names []string, opts ...Option) error {
+	o := getOptions(opts)
+	o.source = strings.Join(names, ",")
+	dst := newOuiDB()
+	t, err := scanFiles(names, dst, o)
 	if err != nil {
 		return err
 	}
-	db.updateDb(dst, t)
-	return nil
+	return replace(db, dst, t, o)
 }
 
 // UpdateHttp will download from a URL and replace the content of the database.
 // The database will remain usable while the updating/parsing
 // is taking place.
-// If an error occurs during read or parsing, the database will not be replaced
+// If an error occurs during read or parsing, or the update is rejected by
+// the UpdatePolicy, the database will not be replaced
 // and the previous version will continue to be served.
-func UpdateHttp(db DynamicDB, url string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
+// If the database was last loaded from the same URL, a conditional request is made,
+// and nothing is updated if the server reports the content hasn't changed.
+// Use UpdateHttpModified to find out if the content was changed.
+func UpdateHttp(db DynamicDB, url string, opts ...Option) error {
+	_, err := UpdateHttpModified(db, url, opts...)
+	return err
+}
+
+// UpdateHttpModified works like UpdateHttp, but also returns whether the content
+// was modified. If the server responds with "304 Not Modified" to the conditional
+// request, the database is left untouched and false is returned with a nil error.
+func UpdateHttpModified(db DynamicDB, url string, opts ...Option) (bool, error) {
+	o := getOptions(opts)
+	o.source = url
+	dst := newOuiDB()
+	t, v, err := scanHttp(url, dst, o, db.validators())
+	if err == errNotModified {
+		return false, nil
 	}
-	defer resp.Body.Close()
-
-	dst := make(ouiDB)
-	t, err := scanOUI(resp.Body, dst)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if err := replace(db, dst, t, o); err != nil {
+		return false, err
 	}
-	db.updateDb(dst, t)
-	return nil
+	db.setValidators(v)
+	return true, nil
 }
 
 // PrintDb the entire database to stdout.
 func PrintDb(db OuiDB) {
-	var hw HardwareAddr
 	c := 0
 	t := time.Now()
-	for i := 0; i < 1<<24; i++ {
-		hw[2] = byte(i & 0xff)
-		hw[1] = byte((i >> 8) & 0xff)
-		hw[0] = byte((i >> 16) & 0xff)
-		e, err := db.LookUp(hw)
-		if err == nil {
-			fmt.Printf("%s\n\n", e.String())
-			c++
-		}
-	}
-	fmt.Printf("Finished reading %d entries in %v. %d Entries.\n", 1<<24, time.Now().Sub(t), c)
+	db.All()(func(e Entry) bool {
+		fmt.Printf("%s\n\n", e.String())
+		c++
+		return true
+	})
+	fmt.Printf("Finished reading %d entries in %v.\n", c, time.Now().Sub(t))
 }
@@ -0,0 +1,48 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+const textMAM = `Generated: Fri, 30 Jan 2015 00:39:43 -0500
+MA-M		Organization
+  00-1B-C6   (hex)		Broken Range
+  100000-2FFFFF     (base 16)		Broken Range
+				Some Street
+				US
+
+  70-B3-D5   (hex)		Tiny IoT
+  F2C000-F2CFFF     (base 16)		Tiny IoT
+				Other Street
+				DE
+`
+
+func TestTextInvalidRange(t *testing.T) {
+	var report ParseReport
+	db, err := Open(strings.NewReader(textMAM), WithFormat(FormatText), WithReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Query("00:1b:c6"); err != ErrNotFound {
+		t.Fatalf("entry with invalid range was stored, got err %v", err)
+	}
+	if len(report.Warnings) != 1 {
+		t.Fatalf("want 1 warning, got %v", report.Warnings)
+	}
+	if n := db.Len(); n != 1 {
+		t.Fatalf("want 1 entry, got %d", n)
+	}
+	e, err := db.Query("70:b3:d5:f2:c1:23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Manufacturer != "Tiny IoT" || e.Block == nil {
+		t.Fatalf("unexpected entry %v", e)
+	}
+
+	_, err = Open(strings.NewReader(textMAM), WithFormat(FormatText), WithStrict())
+	if err == nil {
+		t.Fatal("strict parsing accepted an invalid range")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/klauspost/oui"
+	"log"
+	"os"
+	"strings"
+)
+
+var asJSON = flag.Bool("json", false, "Output the changes as JSON")
+var kinds = flag.String("only", "", "Only output these kinds of changes, separated by commas. Kinds are 'added', 'removed', 'reassigned', 'manufacturer', 'address' and 'other'.")
+
+// Report of all changes.
+type Report struct {
+	Old     string       `json:"old"`
+	New     string       `json:"new"`
+	Changes []oui.Change `json:"changes"`
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <old file> <new file>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Prints the changes between two registry files.")
+		fmt.Fprintln(os.Stderr, "Several files can be given as one argument separated by commas.")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	a, err := oui.OpenStaticFiles(strings.Split(flag.Arg(0), ","))
+	if err != nil {
+		log.Fatalf("Error opening %s:%s", flag.Arg(0), err.Error())
+	}
+	b, err := oui.OpenStaticFiles(strings.Split(flag.Arg(1), ","))
+	if err != nil {
+		log.Fatalf("Error opening %s:%s", flag.Arg(1), err.Error())
+	}
+
+	changes := oui.Diff(a, b)
+	if *kinds != "" {
+		only := make(map[string]bool)
+		for _, k := range strings.Split(*kinds, ",") {
+			only[strings.TrimSpace(k)] = true
+		}
+		var filtered []oui.Change
+		for _, c := range changes {
+			if only[c.Kind.String()] {
+				filtered = append(filtered, c)
+			}
+		}
+		changes = filtered
+	}
+
+	if *asJSON {
+		if changes == nil {
+			changes = []oui.Change{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(Report{Old: flag.Arg(0), New: flag.Arg(1), Changes: changes})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Printf("Changes from %s (generated %s)\n", flag.Arg(0), a.Generated().String())
+	fmt.Printf("          to %s (generated %s)\n\n", flag.Arg(1), b.Generated().String())
+	count := make(map[oui.ChangeKind]int)
+	for _, c := range changes {
+		count[c.Kind]++
+		printChange(c)
+	}
+	fmt.Printf("\n%d added, %d removed, %d reassigned, %d renamed, %d moved, %d other changes.\n",
+		count[oui.ChangeAdded], count[oui.ChangeRemoved], count[oui.ChangeReassigned],
+		count[oui.ChangeManufacturer], count[oui.ChangeAddress], count[oui.ChangeOther])
+}
+
+// Print a change in human readable form.
+func printChange(c oui.Change) {
+	switch c.Kind {
+	case oui.ChangeAdded:
+		fmt.Printf("+ %s %s\n", c.Prefix, c.New.Manufacturer)
+	case oui.ChangeRemoved:
+		fmt.Printf("- %s %s\n", c.Prefix, c.Old.Manufacturer)
+	case oui.ChangeReassigned:
+		fmt.Printf("! %s reassigned from %q to %q\n", c.Prefix, c.Old.Manufacturer, c.New.Manufacturer)
+	case oui.ChangeManufacturer:
+		fmt.Printf("~ %s renamed from %q to %q\n", c.Prefix, c.Old.Manufacturer, c.New.Manufacturer)
+	case oui.ChangeAddress:
+		fmt.Printf("~ %s %s moved from %q to %q\n", c.Prefix, c.New.Manufacturer,
+			strings.Join(c.Old.Address, ", "), strings.Join(c.New.Address, ", "))
+	default:
+		fmt.Printf("~ %s %s changed\n", c.Prefix, c.New.Manufacturer)
+	}
+}
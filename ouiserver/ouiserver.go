@@ -5,10 +5,14 @@ import (
 	"flag"
 	"github.com/gorhill/cronexpr"
 	"github.com/klauspost/oui"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,6 +23,14 @@ var threads = flag.Int("threads", runtime.NumCPU(), "Number of threads to use. D
 var pretty = flag.Bool("pretty", false, "Should output be formatted with newlines and intentation")
 var originPolicy = flag.String("origin", "*", "Value sent in the Access-Control-Allow-Origin header.")
 var update = flag.String("update-every", "", "Duration between reloading the database as 'cronexpr'. Examples are '@weekly', '@monthly'.")
+var timeout = flag.Duration("timeout", 5*time.Minute, "Timeout for downloading the database.")
+var retries = flag.Int("retries", 3, "Number of attempts when downloading the database.")
+var maxDrop = flag.Float64("max-drop", 50, "Reject updates where the number of entries drops by more than this percentage. Set to 0 to disable.")
+var minEntries = flag.Int("min-entries", 0, "Reject updates with fewer entries than this.")
+var history = flag.Int("history", 5, "Number of previous versions of the database to keep for rollback.")
+var admin = flag.String("admin", "", "Listen address and port for admin operations, for instance 127.0.0.1:5001. Disabled if empty.")
+var maxResults = flag.Int("max-results", 50, "Maximum number of results returned by a search. Set to 0 for no limit.")
+var snapshot = flag.String("snapshot", "", "Write a binary snapshot of the database to this file after loading. It can be given to 'open' for faster startup.")
 
 //go:generate: ffjson -nodecoder $(GOFILE)
 
@@ -28,6 +40,12 @@ type Response struct {
 	Error string     `json:"error,omitempty"`
 }
 
+// ffjson: skip
+type SearchResponse struct {
+	Data  []oui.SearchResult `json:"data"`
+	Error string             `json:"error,omitempty"`
+}
+
 func main() {
 	flag.Parse()
 	runtime.GOMAXPROCS(*threads)
@@ -37,6 +55,15 @@ func main() {
 		cron = cronexpr.MustParse(*update)
 	}
 
+	// Options used for downloads.
+	httpOpts := []oui.Option{
+		oui.WithHTTPClient(&http.Client{Timeout: *timeout}),
+		oui.WithRetry(oui.RetryPolicy{Attempts: *retries, Backoff: 10 * time.Second, MaxBackoff: 5 * time.Minute}),
+	}
+
+	// Policy for updates.
+	policy := oui.WithPolicy(oui.UpdatePolicy{MinEntries: *minEntries, MaxDropPercent: *maxDrop})
+
 	var db oui.DynamicDB
 	url := ""
 	fileName := ""
@@ -48,19 +75,20 @@ func main() {
 			url = "http://standards-oui.ieee.org/oui.txt"
 		}
 		log.Println("Downloading new Db from: " + url)
-		db, err = oui.OpenHttp(url)
+		db, err = oui.OpenHttp(url, append(httpOpts, oui.WithHistory(*history))...)
 		if err != nil {
 			log.Fatalf("Error downloading:%s", err.Error())
 		}
 	} else {
 		fileName = *ouiFile
 		log.Println("Opening database from: " + fileName)
-		db, err = oui.OpenFile(fileName)
+		db, err = oui.OpenFile(fileName, oui.WithHistory(*history))
 		if err != nil {
 			log.Fatalf("Error updating file:%s", err.Error())
 		}
 	}
 	log.Printf("Database generated at %s\n", db.Generated().Local().String())
+	writeSnapshot(db)
 
 	// Start updater if needed.
 	if cron != nil {
@@ -72,31 +100,40 @@ func main() {
 				time.Sleep(next.Sub(time.Now()))
 				if url != "" {
 					log.Println("Updating db from: " + url)
-					err := oui.UpdateHttp(db, url)
+					modified, err := oui.UpdateHttpModified(db, url, append(httpOpts, policy)...)
 					if err != nil {
 						log.Printf("Error downloading update:%s", err.Error())
+					} else if !modified {
+						log.Println("Database not modified")
 					} else {
 						log.Println("Updated Successfully")
+						writeSnapshot(db)
 					}
 				} else {
 					log.Println("Updating db with file: " + fileName)
-					err := oui.UpdateFile(db, fileName)
+					err := oui.UpdateFile(db, fileName, policy)
 					if err != nil {
 						log.Printf("Error loading update:%s", err.Error())
 					} else {
 						log.Println("Updated Successfully")
+						writeSnapshot(db)
 					}
 				}
 			}
 		}()
 	}
 
+	if *admin != "" {
+		go serveAdmin(db)
+	}
+
 	// We dereference this to avoid a pretty big penalty under heavy load.
 	prettyL := *pretty
 
+	http.HandleFunc("/search", searchHandler(db, prettyL))
+
 	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
 		var mac string
-		var hw *oui.HardwareAddr
 
 		// Prepare the response and queue sending the result.
 		res := &Response{}
@@ -127,20 +164,20 @@ func main() {
 		if mac == "" {
 			mac = strings.Trim(req.URL.Path, "/")
 		}
-		hw, err := oui.ParseMac(mac)
-		if err != nil {
-			res.Error = err.Error()
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
 
-		entry, err := db.LookUp(*hw)
+		// Query the full address, so the most specific entry is found.
+		entry, err := db.Query(mac)
 		if err != nil {
 			if err == oui.ErrNotFound {
 				res.Error = "not found in db"
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
+			if _, ok := err.(oui.ErrInvalidMac); ok {
+				res.Error = err.Error()
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
 			w.WriteHeader(http.StatusInternalServerError)
 			res.Error = err.Error()
 			return
@@ -151,3 +188,133 @@ func main() {
 	log.Println("Listening on " + *listen)
 	log.Fatal(http.ListenAndServe(*listen, nil))
 }
+
+// Return a handler for ranked manufacturer searches,
+// for instance for autocompletion.
+//
+//	GET /search?q=cisco%20sys&limit=10
+func searchHandler(db oui.OuiDB, pretty bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		res := &SearchResponse{Data: []oui.SearchResult{}}
+		status := http.StatusOK
+
+		defer func() {
+			var j []byte
+			var err error
+			if pretty {
+				j, err = json.MarshalIndent(res, "", "  ")
+			} else {
+				j, err = json.Marshal(res)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			w.WriteHeader(status)
+			w.Write(j)
+		}()
+
+		if *originPolicy != "" {
+			w.Header().Set("Access-Control-Allow-Origin", *originPolicy)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Last-Modified", db.Generated().Format(http.TimeFormat))
+
+		q := req.URL.Query().Get("q")
+		if strings.TrimSpace(q) == "" {
+			res.Error = "missing query"
+			status = http.StatusBadRequest
+			return
+		}
+		limit := 10
+		if l := req.URL.Query().Get("limit"); l != "" {
+			n, err := strconv.Atoi(l)
+			if err != nil || n <= 0 {
+				res.Error = "invalid limit"
+				status = http.StatusBadRequest
+				return
+			}
+			limit = n
+		}
+		if *maxResults > 0 && limit > *maxResults {
+			limit = *maxResults
+		}
+		if r := db.Search(q, limit); r != nil {
+			res.Data = r
+		}
+	}
+}
+
+// Write a snapshot of the database if requested.
+// The snapshot is written to a temporary file that replaces
+// the snapshot when complete, so a crash never leaves a partial snapshot.
+func writeSnapshot(db oui.OuiDB) {
+	if *snapshot == "" {
+		return
+	}
+	f, err := ioutil.TempFile(filepath.Dir(*snapshot), filepath.Base(*snapshot)+".tmp")
+	if err != nil {
+		log.Printf("Error creating snapshot:%s", err.Error())
+		return
+	}
+	tmp := f.Name()
+	err = oui.WriteSnapshot(db, f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, *snapshot)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		log.Printf("Error writing snapshot:%s", err.Error())
+		return
+	}
+	log.Println("Wrote snapshot to: " + *snapshot)
+}
+
+// Serve admin operations on a separate address.
+//
+//	GET /history lists the versions of the database.
+//	POST /rollback?id=N rolls back to a version.
+func serveAdmin(db oui.DynamicDB) {
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/history", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, db.History())
+	})
+	mux.HandleFunc("/rollback", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			writeJSON(w, http.StatusMethodNotAllowed, Response{Error: "use POST"})
+			return
+		}
+		id, err := strconv.Atoi(req.URL.Query().Get("id"))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, Response{Error: "invalid id"})
+			return
+		}
+		err = db.Rollback(id)
+		if err == oui.ErrNoVersion {
+			writeJSON(w, http.StatusNotFound, Response{Error: err.Error()})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, Response{Error: err.Error()})
+			return
+		}
+		log.Printf("Rolled back to version %d", id)
+		writeSnapshot(db)
+		writeJSON(w, http.StatusOK, db.History())
+	})
+	log.Println("Admin listening on " + *admin)
+	log.Fatal(http.ListenAndServe(*admin, mux))
+}
@@ -0,0 +1,102 @@
+package oui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ParseError describes a problem with the input found while parsing.
+// In strict mode the first problem is returned as a *ParseError,
+// otherwise problems are collected in the ParseReport if one is given.
+type ParseError struct {
+	// Source is the file name or URL, if known.
+	Source string
+	// Line is the line number, starting at 1.
+	Line int
+	// Text is the offending text.
+	Text string
+	// Reason describes the problem.
+	Reason string
+}
+
+// Error returns a string representation of the error.
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("line %d: %s: %q", e.Line, e.Reason, e.Text)
+	if e.Source != "" {
+		s = e.Source + ": " + s
+	}
+	return s
+}
+
+// ParseReport collects the problems found while parsing in lenient mode.
+// Give it to the Open or Update functions using WithReport,
+// and inspect it when they return.
+type ParseReport struct {
+	// Warnings contains the problems found.
+	// The lines were skipped, or read as well as possible.
+	Warnings []ParseError
+}
+
+// WithStrict enables strict parsing. Any problem with the input
+// will stop parsing and be returned as a *ParseError.
+// By default problems are ignored, or collected if a ParseReport is given.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithReport will collect problems found while parsing into r.
+// If strict mode is enabled only the error returned will be added.
+func WithReport(r *ParseReport) Option {
+	return func(o *options) {
+		o.report = r
+	}
+}
+
+// The state of parsing, used for reporting problems.
+type parseState struct {
+	source string
+	strict bool
+	report *ParseReport
+}
+
+// Return the parse state given by the options.
+func (o *options) parseState() *parseState {
+	return &parseState{source: o.source, strict: o.strict, report: o.report}
+}
+
+// problem records a problem on a line.
+// In strict mode the problem is returned as a *ParseError,
+// otherwise nil is returned and parsing should continue.
+func (p *parseState) problem(line int, text, reason string) error {
+	e := ParseError{Source: p.source, Line: line, Text: text, Reason: reason}
+	if p.report != nil {
+		p.report.Warnings = append(p.report.Warnings, e)
+	}
+	if p.strict {
+		return &e
+	}
+	return nil
+}
+
+// A bufio.Scanner that counts lines.
+type lineScanner struct {
+	*bufio.Scanner
+	line int
+}
+
+// Create a line scanner reading from r.
+func newLineScanner(r io.Reader) *lineScanner {
+	return &lineScanner{Scanner: bufio.NewScanner(r)}
+}
+
+// Scan advances to the next line.
+func (s *lineScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		return false
+	}
+	s.line++
+	return true
+}
@@ -0,0 +1,98 @@
+package oui
+
+import (
+	"fmt"
+	"time"
+)
+
+// UpdatePolicy contains checks that the new content must pass
+// before it replaces the content of a database on Update,
+// UpdateFile, UpdateFiles and UpdateHttp.
+// If a check fails a *PolicyError is returned and the database
+// will continue to serve the previous content.
+// Zero values disable the checks.
+type UpdatePolicy struct {
+	// MinEntries is the minimum number of entries the new content must have.
+	MinEntries int
+
+	// MaxDropPercent is the maximum percentage the number of entries may drop
+	// compared to the current content, for instance 10 for 10%.
+	MaxDropPercent float64
+
+	// RequireNewer requires the generation time of the new content
+	// to be after the generation time of the current content.
+	RequireNewer bool
+
+	// Validate is called with the current database and a database with the new content.
+	// If an error is returned the update is rejected.
+	Validate func(current, update OuiDB) error
+}
+
+// WithPolicy sets the policy that updates must pass.
+// By default all updates are accepted.
+func WithPolicy(p UpdatePolicy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// PolicyError is returned when an update is rejected by the UpdatePolicy.
+type PolicyError struct {
+	Reason string
+
+	// Err is the error returned by the Validate function, if that rejected the update.
+	Err error
+}
+
+// Error returns a string representation of the error.
+func (e *PolicyError) Error() string {
+	return "update rejected: " + e.Reason
+}
+
+// Unwrap returns the error returned by the Validate function, if any.
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// check returns a *PolicyError if the new content isn't allowed to replace
+// the content of the database.
+func (p UpdatePolicy) check(db DynamicDB, dst ouiDB, t *time.Time) error {
+	n := dst.len()
+	if n < p.MinEntries {
+		return &PolicyError{Reason: fmt.Sprintf("%d entries, at least %d required", n, p.MinEntries)}
+	}
+	if p.MaxDropPercent > 0 {
+		if cur := db.Len(); cur > 0 {
+			drop := float64(cur-n) * 100 / float64(cur)
+			if drop > p.MaxDropPercent {
+				return &PolicyError{Reason: fmt.Sprintf("entries dropped %.1f%% from %d to %d, at most %g%% allowed", drop, cur, n, p.MaxDropPercent)}
+			}
+		}
+	}
+	if p.RequireNewer {
+		cur := db.Generated()
+		switch {
+		case t == nil && !cur.IsZero():
+			return &PolicyError{Reason: "update has no generation time"}
+		case t != nil && !t.After(cur):
+			return &PolicyError{Reason: fmt.Sprintf("update generated at %v is not newer than %v", *t, cur)}
+		}
+	}
+	if p.Validate != nil {
+		update := newStatic(dst)
+		update.generatedAt(t)
+		if err := p.Validate(db, update); err != nil {
+			return &PolicyError{Reason: "validation failed: " + err.Error(), Err: err}
+		}
+	}
+	return nil
+}
+
+// Replace the content of the database if the update policy allows it.
+func replace(db DynamicDB, dst ouiDB, t *time.Time, o *options) error {
+	if err := o.policy.check(db, dst, t); err != nil {
+		return err
+	}
+	db.updateDb(dst, t, o.source)
+	return nil
+}
@@ -0,0 +1,76 @@
+package oui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Return a snapshot with the nmap content and generation time.
+func policyUpdate(t *testing.T, in string, gen time.Time) []byte {
+	db, err := OpenStatic(strings.NewReader(in), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gen.IsZero() {
+		db.generatedAt(&gen)
+	}
+	return snapshotOf(t, db)
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	errInvalid := errors.New("missing IBM")
+	validate := func(current, update OuiDB) error {
+		if _, err := update.Query("00:60:94"); err != nil {
+			return errInvalid
+		}
+		return nil
+	}
+	gen := testDB(t).Generated()
+	older, newer := gen.Add(-time.Hour), gen.Add(time.Hour)
+	three := "006092 A\n006094 B\n006096 C\n"
+
+	for _, tc := range []struct {
+		name   string
+		policy UpdatePolicy
+		update []byte
+		ok     bool
+	}{
+		{"min entries", UpdatePolicy{MinEntries: 2}, policyUpdate(t, "000001 One\n", newer), false},
+		{"min entries ok", UpdatePolicy{MinEntries: 3}, policyUpdate(t, three, newer), true},
+		{"max drop", UpdatePolicy{MaxDropPercent: 50}, policyUpdate(t, "000001 One\n", newer), false},
+		{"max drop ok", UpdatePolicy{MaxDropPercent: 50}, policyUpdate(t, three, newer), true},
+		{"not newer", UpdatePolicy{RequireNewer: true}, policyUpdate(t, three, older), false},
+		{"same time", UpdatePolicy{RequireNewer: true}, policyUpdate(t, three, gen), false},
+		{"no time", UpdatePolicy{RequireNewer: true}, policyUpdate(t, three, time.Time{}), false},
+		{"newer", UpdatePolicy{RequireNewer: true}, policyUpdate(t, three, newer), true},
+		{"validate", UpdatePolicy{Validate: validate}, policyUpdate(t, "006092 A\n", newer), false},
+		{"validate ok", UpdatePolicy{Validate: validate}, policyUpdate(t, three, newer), true},
+	} {
+		db := testDynamic(t)
+		before := db.(*updateableDB).load()
+		err := Update(db, bytes.NewReader(tc.update), WithPolicy(tc.policy))
+		if tc.ok {
+			if err != nil || db.Len() != 3 {
+				t.Errorf("%s: update rejected: %v", tc.name, err)
+			}
+			continue
+		}
+		perr, ok := err.(*PolicyError)
+		if !ok {
+			t.Errorf("%s: want *PolicyError, got %v", tc.name, err)
+			continue
+		}
+		if perr.Reason == "" {
+			t.Errorf("%s: no reason given", tc.name)
+		}
+		if db.(*updateableDB).load() != before {
+			t.Errorf("%s: previous content not kept", tc.name)
+		}
+		if tc.policy.Validate != nil && errors.Unwrap(err) != errInvalid {
+			t.Errorf("%s: unwrapped %v, want %v", tc.name, errors.Unwrap(err), errInvalid)
+		}
+	}
+}
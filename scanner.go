@@ -0,0 +1,165 @@
+package oui
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// A reader of entries in one of the supported formats.
+type entryReader interface {
+	// Return the next entry.
+	// When there are no more entries io.EOF is returned.
+	next() (*Entry, error)
+
+	// Return the generation time, if it has been read.
+	generated() *time.Time
+}
+
+// Scanner reads entries one at a time from a registry in any of the
+// supported formats. It works like bufio.Scanner:
+//
+//	s := oui.NewScanner(file)
+//	for s.Scan() {
+//		e := s.Entry()
+//		// Store e.
+//	}
+//	if err := s.Err(); err != nil {
+//		// Handle the error.
+//	}
+//
+// Only the current entry is held in memory, except for snapshots
+// which are read entirely before the first entry is returned.
+// Options for format, compression and parsing are respected.
+type Scanner struct {
+	in     io.Reader
+	o      *options
+	r      entryReader
+	entry  Entry
+	format Format
+	err    error
+	done   func()
+}
+
+// NewScanner returns a Scanner reading from in.
+// Compression and format are detected on the first call to Scan,
+// unless they are given as options.
+func NewScanner(in io.Reader, opts ...Option) *Scanner {
+	return newScanner(in, getOptions(opts))
+}
+
+// Create a scanner with the given options.
+func newScanner(in io.Reader, o *options) *Scanner {
+	return &Scanner{in: in, o: o, done: func() {}}
+}
+
+// Decompress the input and create the reader for the format.
+func (s *Scanner) start() error {
+	dr, done, err := decompress(bufio.NewReader(s.in), s.o)
+	if err != nil {
+		return err
+	}
+	s.done = done
+	r, ok := dr.(*bufio.Reader)
+	if !ok {
+		r = bufio.NewReader(dr)
+	}
+	s.format = s.o.format
+	if s.format == FormatAuto {
+		s.format = detectFormat(r)
+	}
+	p := s.o.parseState()
+	switch s.format {
+	case FormatCSV:
+		s.r = newCSVReader(r, p)
+	case FormatManuf:
+		s.r = newManufReader(r, p)
+	case FormatNmap:
+		s.r = newNmapReader(r, p)
+	case FormatJSONL:
+		s.r = newJSONLReader(r, p)
+	case FormatSnapshot:
+		s.r = newSnapshotEntryReader(r)
+	default:
+		s.format = FormatText
+		s.r = newTextReader(r, p)
+	}
+	return nil
+}
+
+// Scan advances to the next entry, which will then be available
+// through the Entry method. It returns false when there are no more
+// entries, either by reaching the end of the input or an error.
+// After Scan returns false, Err will return any error that occurred,
+// except that if it was io.EOF, Err will return nil.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.r == nil {
+		if err := s.start(); err != nil {
+			s.stop(err)
+			return false
+		}
+	}
+	e, err := s.r.next()
+	if err != nil {
+		s.stop(err)
+		return false
+	}
+	s.entry = *e
+	return true
+}
+
+// Stop scanning with the given error.
+func (s *Scanner) stop(err error) {
+	s.err = err
+	s.done()
+	s.done = func() {}
+}
+
+// Entry returns the most recent entry read by a call to Scan.
+func (s *Scanner) Entry() Entry {
+	return s.entry
+}
+
+// Generated returns the generation time of the registry.
+// The time is only available once it has been read,
+// so it may not be set until all entries have been read.
+// If no time has been read the zero time is returned.
+func (s *Scanner) Generated() time.Time {
+	if s.r == nil {
+		return time.Time{}
+	}
+	if t := s.r.generated(); t != nil {
+		return *t
+	}
+	return time.Time{}
+}
+
+// Format returns the format of the input.
+// Until Scan has been called the format given as option is returned.
+func (s *Scanner) Format() Format {
+	if s.r == nil {
+		return s.o.format
+	}
+	return s.format
+}
+
+// Err returns the first error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// Close releases the resources used for decompression.
+// It is only needed if scanning is stopped before Scan returns false.
+// It does not close the underlying reader.
+func (s *Scanner) Close() error {
+	if s.err == nil {
+		s.stop(io.EOF)
+	}
+	return nil
+}
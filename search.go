@@ -0,0 +1,130 @@
+package oui
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MatchMode is how names are matched when searching by manufacturer.
+type MatchMode int
+
+const (
+	// MatchExact matches names that are equal to the search.
+	MatchExact MatchMode = iota
+	// MatchFold matches names that are equal to the search, ignoring case.
+	MatchFold
+	// MatchPrefix matches names starting with the search, ignoring case.
+	MatchPrefix
+	// MatchContains matches names containing the search, ignoring case.
+	MatchContains
+)
+
+// String returns the name of the match mode.
+func (m MatchMode) String() string {
+	switch m {
+	case MatchExact:
+		return "exact"
+	case MatchFold:
+		return "fold"
+	case MatchPrefix:
+		return "prefix"
+	case MatchContains:
+		return "contains"
+	}
+	return "unknown"
+}
+
+// An index of entries by manufacturer name.
+// The index is built on first use. Content that is changed
+// gets a new index, so an index never has to be updated.
+type nameIndex struct {
+	once sync.Once
+	// Lower case names, sorted.
+	names []string
+	// The entries of each name, sorted by prefix.
+	entries [][]Entry
+}
+
+// Build the index from the entries.
+func (x *nameIndex) build(entries []Entry) {
+	byName := make(map[string][]Entry)
+	for _, e := range entries {
+		l := strings.ToLower(e.Manufacturer)
+		byName[l] = append(byName[l], e)
+	}
+	x.names = make([]string, 0, len(byName))
+	for l := range byName {
+		x.names = append(x.names, l)
+	}
+	sort.Strings(x.names)
+	x.entries = make([][]Entry, len(x.names))
+	for i, l := range x.names {
+		x.entries[i] = byName[l]
+	}
+}
+
+// Search the index, building it from the entries if needed.
+// The entries must be sorted by prefix.
+func (x *nameIndex) search(entries func() []Entry, name string, m MatchMode) []Entry {
+	x.once.Do(func() {
+		x.build(entries())
+	})
+	lower := strings.ToLower(name)
+	var res []Entry
+	switch m {
+	case MatchContains:
+		for i, l := range x.names {
+			if strings.Contains(l, lower) {
+				res = append(res, x.entries[i]...)
+			}
+		}
+	default:
+		for i := sort.SearchStrings(x.names, lower); i < len(x.names); i++ {
+			l := x.names[i]
+			if l != lower && (m != MatchPrefix || !strings.HasPrefix(l, lower)) {
+				break
+			}
+			for _, e := range x.entries[i] {
+				if m != MatchExact || e.Manufacturer == name {
+					res = append(res, e)
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return entryLess(&res[i], &res[j])
+	})
+	return res
+}
+
+// SearchManufacturer returns all entries where the manufacturer matches name,
+// sorted by prefix.
+func (o staticDB) SearchManufacturer(name string, m MatchMode) []Entry {
+	return o.ouiDB.idx.search(o.entries, name, m)
+}
+
+// SearchManufacturer returns all entries where the manufacturer matches name,
+// sorted by prefix.
+// The index is kept for the current content, and is rebuilt on the first search
+// after the content has changed.
+func (o *updateableDB) SearchManufacturer(name string, m MatchMode) []Entry {
+	s := o.load()
+	return s.idx.search(s.sorted, name, m)
+}
+
+// SearchManufacturer returns all entries where the manufacturer matches name,
+// sorted by prefix.
+// The index is built on first search.
+func (db *mappedDB) SearchManufacturer(name string, m MatchMode) []Entry {
+	return db.idx.search(db.entries, name, m)
+}
+
+// SearchManufacturer returns all entries where the manufacturer matches name,
+// sorted by prefix.
+// Entries hidden by an entry of a layer before them are not included.
+// The index is kept until a layer is replaced or updated.
+func (db *layeredDB) SearchManufacturer(name string, m MatchMode) []Entry {
+	c := db.current()
+	return c.idx.search(c.sorted, name, m)
+}
@@ -0,0 +1,77 @@
+package oui
+
+import (
+	"strings"
+	"testing"
+)
+
+const searchInput = "000001 Acme Corp\n000002 ACME CORP\n000003 Acme Robotics\n000004 Big Acme\n000005 Other\n"
+
+func TestSearchManufacturer(t *testing.T) {
+	static, err := OpenStatic(strings.NewReader(searchInput), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamic, err := Open(strings.NewReader(searchInput), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapped, err := OpenMapped(writeIndexFile(t, static))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+
+	for _, tc := range []struct {
+		name string
+		mode MatchMode
+		want string
+	}{
+		{"Acme Corp", MatchExact, "00:00:01"},
+		{"acme corp", MatchExact, ""},
+		{"acme corp", MatchFold, "00:00:01,00:00:02"},
+		{"acme", MatchFold, ""},
+		{"ACME", MatchPrefix, "00:00:01,00:00:02,00:00:03"},
+		{"acme r", MatchPrefix, "00:00:03"},
+		{"acme", MatchContains, "00:00:01,00:00:02,00:00:03,00:00:04"},
+		{"CORP", MatchContains, "00:00:01,00:00:02"},
+		{"missing", MatchContains, ""},
+	} {
+		for _, db := range []OuiDB{static, dynamic, mapped} {
+			var got []string
+			for _, e := range db.SearchManufacturer(tc.name, tc.mode) {
+				got = append(got, e.Prefix.String())
+			}
+			if strings.Join(got, ",") != tc.want {
+				t.Errorf("%T %q %v: got %q, want %q", db, tc.name, tc.mode, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestSearchManufacturerUpdate(t *testing.T) {
+	db, err := Open(strings.NewReader(searchInput), WithFormat(FormatNmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := db.SearchManufacturer("acme", MatchPrefix); len(res) != 3 {
+		t.Fatalf("unexpected result %v", res)
+	}
+
+	// The index follows the new content.
+	if err := Update(db, strings.NewReader("000001 Acme Widgets\n000006 Other\n"), WithFormat(FormatNmap)); err != nil {
+		t.Fatal(err)
+	}
+	if res := db.SearchManufacturer("acme", MatchPrefix); len(res) != 1 || res[0].Manufacturer != "Acme Widgets" {
+		t.Fatalf("index not rebuilt after update: %v", res)
+	}
+	if res := db.SearchManufacturer("other", MatchFold); len(res) != 1 || res[0].Prefix != (HardwareAddr{0, 0, 6}) {
+		t.Fatalf("index not rebuilt after update: %v", res)
+	}
+
+	hw := HardwareAddr{0, 0, 7}
+	db.UpdateEntry(hw, Entry{Prefix: hw, Manufacturer: "Acme Tools"})
+	if res := db.SearchManufacturer("acme", MatchPrefix); len(res) != 2 {
+		t.Fatalf("index not rebuilt after UpdateEntry: %v", res)
+	}
+}
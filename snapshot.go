@@ -0,0 +1,328 @@
+package oui
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// The snapshot format is:
+//
+//	magic       [8]byte "OUISNAP\x00"
+//	version     uvarint
+//	generated   varint, unix nanoseconds or 0 if unknown
+//	strings     uvarint count, followed by uvarint length and bytes of each string
+//	entries     uvarint count, followed by each entry
+//	checksum    uint32 little endian CRC32C of everything before it
+//
+// Each entry is written as:
+//
+//	prefix      [3]byte
+//	bits        byte, 24 for OUI entries
+//	block       [3]byte, only present if bits > 24
+//	strings     uvarint string index of Manufacturer, ShortName, Registry and Country
+//	address     uvarint count, followed by uvarint string index of each line
+//
+// String index 0 is always the empty string.
+
+// The magic bytes a snapshot starts with.
+const snapshotMagic = "OUISNAP\x00"
+
+// The current snapshot version.
+const snapshotVersion = 1
+
+// ErrInvalidSnapshot is returned if a snapshot is truncated,
+// fails the checksum or otherwise cannot be decoded.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+var snapshotTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteSnapshot will write the database to w as a binary snapshot.
+// A snapshot can be read much faster than the other formats,
+// and can be opened with OpenSnapshot/OpenStaticSnapshot or any other
+// Open/Update function, since the format is detected automatically.
+func WriteSnapshot(db OuiDB, w io.Writer) error {
+	entries := db.entries()
+	crc := crc32.New(snapshotTable)
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+
+	var tmp [binary.MaxVarintLen64]byte
+	uvarint := func(v uint64) {
+		bw.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+	}
+
+	bw.WriteString(snapshotMagic)
+	uvarint(snapshotVersion)
+	var gen int64
+	if t := db.Generated(); !t.IsZero() {
+		gen = t.UnixNano()
+	}
+	bw.Write(tmp[:binary.PutVarint(tmp[:], gen)])
+
+	// Collect the strings, so repeated strings are only written once.
+	index := map[string]uint64{"": 0}
+	strs := []string{""}
+	add := func(s string) {
+		if _, ok := index[s]; !ok {
+			index[s] = uint64(len(strs))
+			strs = append(strs, s)
+		}
+	}
+	for i := range entries {
+		e := &entries[i]
+		add(e.Manufacturer)
+		add(e.ShortName)
+		add(string(e.Registry))
+		add(e.Country)
+		for _, a := range e.Address {
+			add(a)
+		}
+	}
+	uvarint(uint64(len(strs)))
+	for _, s := range strs {
+		uvarint(uint64(len(s)))
+		bw.WriteString(s)
+	}
+
+	uvarint(uint64(len(entries)))
+	for i := range entries {
+		e := &entries[i]
+		bw.Write(e.Prefix[:])
+		if e.Block != nil {
+			bw.WriteByte(byte(e.Block.Bits))
+			bw.Write(e.Block.Addr[3:])
+		} else {
+			bw.WriteByte(24)
+		}
+		uvarint(index[e.Manufacturer])
+		uvarint(index[e.ShortName])
+		uvarint(index[string(e.Registry)])
+		uvarint(index[e.Country])
+		uvarint(uint64(len(e.Address)))
+		for _, a := range e.Address {
+			uvarint(index[a])
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc.Sum32())
+	_, err := w.Write(sum[:])
+	return err
+}
+
+// A reader for snapshot content already in memory.
+type snapshotReader struct {
+	b   []byte
+	err error
+}
+
+func (r *snapshotReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = ErrInvalidSnapshot
+		r.b = nil
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *snapshotReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.err = ErrInvalidSnapshot
+		r.b = nil
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// Return the next n bytes.
+// The length is read from the input, so nothing is allocated
+// if there are fewer bytes left. Nil is returned instead.
+func (r *snapshotReader) bytes(n uint64) []byte {
+	if uint64(len(r.b)) < n {
+		r.err = ErrInvalidSnapshot
+		r.b = nil
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *snapshotReader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// Reads a binary snapshot written by WriteSnapshot.
+// The snapshot is read and verified entirely before the first entry is returned.
+type snapshotEntryReader struct {
+	in    io.Reader
+	r     *snapshotReader
+	gen   *time.Time
+	strs  []string
+	count uint64
+	read  uint64
+
+	// Address lines are sliced from a shared slice.
+	addrs []string
+}
+
+// Create a reader of the snapshot format.
+func newSnapshotEntryReader(in io.Reader) *snapshotEntryReader {
+	return &snapshotEntryReader{in: in}
+}
+
+// Return the generation time, if it has been read.
+func (s *snapshotEntryReader) generated() *time.Time {
+	return s.gen
+}
+
+// Read the snapshot, verify it and read the string table.
+func (s *snapshotEntryReader) start() error {
+	b, err := ioutil.ReadAll(s.in)
+	if err != nil {
+		return err
+	}
+	if len(b) < len(snapshotMagic)+4 || string(b[:len(snapshotMagic)]) != snapshotMagic {
+		return ErrInvalidSnapshot
+	}
+	body := b[:len(b)-4]
+	if crc32.Checksum(body, snapshotTable) != binary.LittleEndian.Uint32(b[len(b)-4:]) {
+		return ErrInvalidSnapshot
+	}
+	r := &snapshotReader{b: body[len(snapshotMagic):]}
+	if v := r.uvarint(); v != snapshotVersion {
+		return fmt.Errorf("snapshot: unsupported version %d", v)
+	}
+	if gen := r.varint(); gen != 0 {
+		t := time.Unix(0, gen)
+		s.gen = &t
+	}
+
+	// All strings are sliced from a single allocation.
+	n := r.uvarint()
+	if n == 0 || n > uint64(len(r.b)) {
+		return ErrInvalidSnapshot
+	}
+	start := r.b
+	lens := make([]uint64, n)
+	var total uint64
+	for i := range lens {
+		lens[i] = r.uvarint()
+		r.bytes(lens[i])
+		total += lens[i]
+	}
+	if r.err != nil {
+		return r.err
+	}
+	all := make([]byte, 0, total)
+	for _, l := range lens {
+		_, n := binary.Uvarint(start)
+		all = append(all, start[n:n+int(l)]...)
+		start = start[n+int(l):]
+	}
+	allStr := string(all)
+	s.strs = make([]string, len(lens))
+	var pos uint64
+	for i, l := range lens {
+		s.strs[i] = allStr[pos : pos+l]
+		pos += l
+	}
+
+	s.count = r.uvarint()
+	if s.count > uint64(len(r.b)) {
+		return ErrInvalidSnapshot
+	}
+	s.r = r
+	return nil
+}
+
+// Return a string from the string table.
+func (s *snapshotEntryReader) str() string {
+	i := s.r.uvarint()
+	if i >= uint64(len(s.strs)) {
+		s.r.err = ErrInvalidSnapshot
+		return ""
+	}
+	return s.strs[i]
+}
+
+// Return the next entry.
+func (s *snapshotEntryReader) next() (*Entry, error) {
+	if s.r == nil {
+		if err := s.start(); err != nil {
+			return nil, err
+		}
+	}
+	r := s.r
+	if s.read == s.count {
+		if len(r.b) != 0 {
+			return nil, ErrInvalidSnapshot
+		}
+		return nil, io.EOF
+	}
+	s.read++
+	var e Entry
+	copy(e.Prefix[:], r.bytes(3))
+	bits := int(r.uint8())
+	if bits != 24 {
+		if bits < 24 || bits > 48 {
+			return nil, ErrInvalidSnapshot
+		}
+		var m MAC
+		copy(m[:3], e.Prefix[:])
+		copy(m[3:], r.bytes(3))
+		b := NewBlock(m, bits)
+		e.Block = &b
+	}
+	e.Manufacturer = s.str()
+	e.ShortName = s.str()
+	e.Registry = Registry(s.str())
+	e.Country = s.str()
+	if na := r.uvarint(); na > 0 {
+		if na > uint64(len(r.b)) {
+			return nil, ErrInvalidSnapshot
+		}
+		if uint64(cap(s.addrs)-len(s.addrs)) < na {
+			s.addrs = make([]string, 0, 4*(s.count-s.read)+na)
+		}
+		for j := uint64(0); j < na; j++ {
+			s.addrs = append(s.addrs, s.str())
+		}
+		e.Address = s.addrs[len(s.addrs)-int(na) : len(s.addrs) : len(s.addrs)]
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	e.Local = e.Prefix.Local()
+	e.Multicast = e.Prefix.Multicast()
+	return &e, nil
+}
+
+// OpenStaticSnapshot will read a binary snapshot from the given reader
+// and return a database with the content.
+// This is the same as calling OpenStatic with the FormatSnapshot option.
+func OpenStaticSnapshot(in io.Reader, opts ...Option) (StaticDB, error) {
+	return OpenStatic(in, append(opts, WithFormat(FormatSnapshot))...)
+}
+
+// OpenSnapshot will read a binary snapshot from the given reader
+// and return a database with the content.
+// This is the same as calling Open with the FormatSnapshot option.
+func OpenSnapshot(in io.Reader, opts ...Option) (DynamicDB, error) {
+	return Open(in, append(opts, WithFormat(FormatSnapshot))...)
+}
@@ -0,0 +1,86 @@
+package oui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	db := testDB(t)
+	var buf bytes.Buffer
+	if err := WriteSnapshot(db, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := OpenStaticSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Generated().Equal(db.Generated()) {
+		t.Errorf("generated %v, want %v", got.Generated(), db.Generated())
+	}
+	a, b := db.entries(), got.entries()
+	if len(a) != len(b) {
+		t.Fatalf("got %d entries, want %d", len(b), len(a))
+	}
+	for i := range a {
+		if !entryEqual(&a[i], &b[i]) {
+			t.Errorf("got %v, want %v", b[i], a[i])
+		}
+	}
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(testDB(t), &buf); err != nil {
+		t.Fatal(err)
+	}
+	snap := buf.Bytes()
+
+	// Flip a bit in the middle, so the checksum fails.
+	corrupt := append([]byte(nil), snap...)
+	corrupt[len(corrupt)/2] ^= 1
+	if _, err := OpenStaticSnapshot(bytes.NewReader(corrupt)); err != ErrInvalidSnapshot {
+		t.Errorf("corrupt snapshot: want ErrInvalidSnapshot, got %v", err)
+	}
+
+	// Truncated snapshots.
+	for _, n := range []int{len(snapshotMagic) + 1, len(snap) / 2, len(snap) - 1} {
+		if _, err := OpenStaticSnapshot(bytes.NewReader(snap[:n])); err == nil {
+			t.Errorf("snapshot truncated to %d bytes accepted", n)
+		}
+	}
+}
+
+// Return v as an uvarint.
+func uvarint(v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return tmp[:binary.PutUvarint(tmp[:], v)]
+}
+
+// Return a snapshot with the body and a valid checksum.
+func craftSnapshot(body ...[]byte) []byte {
+	b := append([]byte(snapshotMagic), uvarint(snapshotVersion)...)
+	// No generation time.
+	b = append(b, 0)
+	for _, p := range body {
+		b = append(b, p...)
+	}
+	var crc [4]byte
+	binary.LittleEndian.PutUint32(crc[:], crc32.Checksum(b, snapshotTable))
+	return append(b, crc[:]...)
+}
+
+func TestSnapshotCrafted(t *testing.T) {
+	for name, snap := range map[string][]byte{
+		// One string with a length far beyond the input.
+		"string length": craftSnapshot(uvarint(1), uvarint(1<<62), make([]byte, 16)),
+		// A valid string table, and an entry cut off after the prefix.
+		"entry": craftSnapshot([]byte{1, 1, 'a', 1, 0, 0, 1}),
+	} {
+		if _, err := OpenSnapshot(bytes.NewReader(snap)); err != ErrInvalidSnapshot {
+			t.Errorf("%s: want ErrInvalidSnapshot, got %v", name, err)
+		}
+	}
+}